@@ -0,0 +1,282 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/arglp/chirpy/internal/mailer"
+	"github.com/google/uuid"
+)
+
+const (
+	dataExportPending = "pending"
+	dataExportRunning = "running"
+	dataExportReady   = "ready"
+	dataExportFailed  = "failed"
+
+	dataExportBatch = 5
+)
+
+// scheduleAccountDeletion marks the user's account for deletion once the
+// grace period is over and tells them by mail how to change their mind.
+// The account keeps working until then so that they can.
+func (cfg *apiConfig) scheduleAccountDeletion(ctx context.Context, user database.User) (database.User, error) {
+	deleteAt := time.Now().Add(cfg.accountDeletionGracePeriod)
+	scheduled, err := cfg.dbQueries.ScheduleUserDeletion(ctx, database.ScheduleUserDeletionParams{
+		ID:                  user.ID,
+		DeletionScheduledAt: sql.NullTime{Time: deleteAt, Valid: true},
+	})
+	if err != nil {
+		return database.User{}, err
+	}
+	cfg.recordAudit(ctx, auditEvent{
+		Action:     auditUserDeletionScheduled,
+		TargetType: auditTargetUser,
+		TargetID:   user.ID,
+		After:      map[string]any{"deletion_scheduled_at": deleteAt},
+	})
+
+	err = cfg.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your Chirpy account will be deleted",
+		Body: fmt.Sprintf("Your Chirpy account and everything in it will be deleted on %s.\n\n"+
+			"If you didn't mean to delete it, log in before then and cancel the deletion.\n",
+			deleteAt.UTC().Format(time.RFC1123)),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "couldn't send deletion notice", "user_id", user.ID, "error", err)
+	}
+	return scheduled, nil
+}
+
+// purgeDeletedAccounts deletes every account whose grace period has ended.
+// Everything the user owns goes with them through the schema's cascades;
+// the audit log keeps only the fact of the deletion. Each deletion is made
+// in one transaction with its audit entry, so no account disappears
+// without a trace, and only if it is still due: a cancellation that lands
+// after the accounts are listed wins. One failed deletion doesn't hold up
+// the others.
+func (cfg *apiConfig) purgeDeletedAccounts(ctx context.Context) error {
+	users, err := cfg.dbQueries.GetUsersDueForDeletion(ctx)
+	if err != nil {
+		return err
+	}
+	var failed error
+	for _, user := range users {
+		err = cfg.withTx(ctx, func(q database.Querier) error {
+			deleted, err := q.DeleteUserIfDue(ctx, user.ID)
+			if err != nil || deleted == 0 {
+				return err
+			}
+			return writeAudit(ctx, q, auditEvent{
+				Action:     auditUserDeleted,
+				TargetType: auditTargetUser,
+				TargetID:   user.ID,
+				Before:     map[string]any{"deletion_scheduled_at": user.DeletionScheduledAt.Time},
+			})
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "couldn't purge account", "user_id", user.ID, "error", err)
+			failed = errors.Join(failed, err)
+		}
+	}
+	return failed
+}
+
+func (cfg *apiConfig) runAccountPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := cfg.purgeDeletedAccounts(ctx)
+		if err != nil {
+			slog.Error("couldn't purge deleted accounts", "error", err)
+		}
+		cfg.heartbeats.beat(workerAccountPurge, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// generateDataExports builds the archives for one batch of requested
+// exports and drops the ones that have expired. An export that can't be
+// built is marked failed so the user can ask for a new one.
+func (cfg *apiConfig) generateDataExports(ctx context.Context) error {
+	_, err := cfg.dbQueries.DeleteExpiredDataExports(ctx)
+	if err != nil {
+		return err
+	}
+	exports, err := cfg.dbQueries.ClaimPendingDataExports(ctx, dataExportBatch)
+	if err != nil {
+		return err
+	}
+	for _, export := range exports {
+		finished := database.FinishDataExportParams{
+			ID:        export.ID,
+			Status:    dataExportReady,
+			ExpiresAt: sql.NullTime{Time: time.Now().Add(cfg.dataExportTTL), Valid: true},
+		}
+		archive, buildErr := cfg.buildDataExport(ctx, export.UserID)
+		if buildErr != nil {
+			slog.ErrorContext(ctx, "couldn't build data export", "export_id", export.ID, "error", buildErr)
+			finished.Status = dataExportFailed
+		} else {
+			finished.Archive = archive
+		}
+		err = cfg.dbQueries.FinishDataExport(ctx, finished)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) runDataExport(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := cfg.generateDataExports(ctx)
+		if err != nil {
+			slog.Error("couldn't generate data exports", "error", err)
+		}
+		cfg.heartbeats.beat(workerDataExport, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Session is a login session in a data export. Token values are left out:
+// the export is about what the account holds, not a way to sign in.
+type Session struct {
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	ClientID  *uuid.UUID `json:"client_id"`
+	Scopes    []string   `json:"scopes"`
+}
+
+// buildDataExport collects everything held about the user into a ZIP of
+// JSON files: the profile, their chirps, their sessions and access tokens
+// and their subscription history.
+func (cfg *apiConfig) buildDataExport(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	type profile struct {
+		ID                  uuid.UUID  `json:"id"`
+		CreatedAt           time.Time  `json:"created_at"`
+		UpdatedAt           time.Time  `json:"updated_at"`
+		Email               string     `json:"email"`
+		IsChirpyRed         bool       `json:"is_chirpy_red"`
+		Role                string     `json:"role"`
+		SuspendedUntil      *time.Time `json:"suspended_until"`
+		DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
+	}
+	type sessions struct {
+		Sessions             []Session             `json:"sessions"`
+		PersonalAccessTokens []PersonalAccessToken `json:"personal_access_tokens"`
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	p := profile{
+		ID:          user.ID,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+		Email:       user.Email,
+		IsChirpyRed: user.IsChirpyRed,
+		Role:        user.Role,
+	}
+	if user.SuspendedUntil.Valid {
+		p.SuspendedUntil = &user.SuspendedUntil.Time
+	}
+	if user.DeletionScheduledAt.Valid {
+		p.DeletionScheduledAt = &user.DeletionScheduledAt.Time
+	}
+
+	dChirps, err := cfg.dbQueries.GetChirpsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	chirps := []Chirp{}
+	for _, dC := range dChirps {
+		chirps = append(chirps, transcribeChirp(dC))
+	}
+
+	refreshTokens, err := cfg.dbQueries.GetRefreshTokensForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	accessTokens, err := cfg.dbQueries.GetPersonalAccessTokensForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s := sessions{Sessions: []Session{}, PersonalAccessTokens: []PersonalAccessToken{}}
+	for _, t := range refreshTokens {
+		session := Session{CreatedAt: t.CreatedAt, ExpiresAt: t.ExpiresAt, Scopes: t.Scopes}
+		if t.RevokedAt.Valid {
+			session.RevokedAt = &t.RevokedAt.Time
+		}
+		if t.ClientID.Valid {
+			session.ClientID = &t.ClientID.UUID
+		}
+		s.Sessions = append(s.Sessions, session)
+	}
+	for _, t := range accessTokens {
+		s.PersonalAccessTokens = append(s.PersonalAccessTokens, transcribePersonalAccessToken(t))
+	}
+
+	var subscription *Subscription
+	dS, err := cfg.dbQueries.GetSubscriptionByUser(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err == nil {
+		events, err := cfg.dbQueries.GetSubscriptionEvents(ctx, dS.ID)
+		if err != nil {
+			return nil, err
+		}
+		transcribed := transcribeSubscription(dS, events)
+		subscription = &transcribed
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, file := range []struct {
+		name string
+		data any
+	}{
+		{"profile.json", p},
+		{"chirps.json", chirps},
+		{"sessions.json", s},
+		{"subscription.json", subscription},
+	} {
+		f, err := archive.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(file.data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = archive.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
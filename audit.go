@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	auditChirpDeleted          = "chirp.deleted"
+	auditChirpHidden           = "chirp.hidden"
+	auditUserUpdated           = "user.updated"
+	auditUserRoleChanged       = "user.role_changed"
+	auditUserChirpyRedChanged  = "user.chirpy_red_changed"
+	auditUserSuspended         = "user.suspended"
+	auditUserUnsuspended       = "user.unsuspended"
+	auditUserShadowbanned      = "user.shadowbanned"
+	auditUserUnshadowbanned    = "user.unshadowbanned"
+	auditUserDeletionScheduled = "user.deletion_scheduled"
+	auditUserDeletionCancelled = "user.deletion_cancelled"
+	auditUserDeleted           = "user.deleted"
+	auditWebhookReplayed       = "webhook.replayed"
+	auditReset                 = "admin.reset"
+
+	auditTargetChirp          = "chirp"
+	auditTargetUser           = "user"
+	auditTargetInboundWebhook = "inbound_webhook"
+	auditTargetSystem         = "system"
+)
+
+const clientIPContextKey contextKey = "client_ip"
+
+// middlewareClientIP records the address of the connecting client for the
+// audit log. X-Forwarded-For is ignored: nothing in front of Chirpy is
+// trusted to set it.
+func middlewareClientIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+		ctx := context.WithValue(r.Context(), clientIPContextKey, ip)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func clientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPContextKey).(string)
+	return ip
+}
+
+// auditEvent describes a change for the audit log. Before and After are
+// snapshots of the target marshalled to JSON; leave them nil when there is
+// nothing to show, as for a creation or a deletion.
+type auditEvent struct {
+	Action     string
+	TargetType string
+	TargetID   uuid.UUID
+	Before     any
+	After      any
+}
+
+// auditTime snapshots a nullable timestamp, as null when it isn't set.
+func auditTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// recordAudit appends event to the audit log. The actor, client IP and
+// request ID are taken from ctx, so they are empty for changes made by the
+// CLI or by background workers. Failures are logged rather than returned:
+// by the time a change is audited it has already been made.
+func (cfg *apiConfig) recordAudit(ctx context.Context, event auditEvent) {
+	err := writeAudit(ctx, cfg.dbQueries, event)
+	if err != nil {
+		slog.ErrorContext(ctx, "couldn't write audit event", "action", event.Action, "target_id", event.TargetID, "error", err)
+	}
+}
+
+// writeAudit appends event to the audit log through q and returns any
+// failure, for changes made in a transaction that should not go through
+// unaudited.
+func writeAudit(ctx context.Context, q database.Querier, event auditEvent) error {
+	before, err := json.Marshal(event.Before)
+	if err != nil {
+		return err
+	}
+	after, err := json.Marshal(event.After)
+	if err != nil {
+		return err
+	}
+
+	params := database.CreateAuditEventParams{
+		Action:      event.Action,
+		TargetType:  event.TargetType,
+		TargetID:    uuid.NullUUID{UUID: event.TargetID, Valid: event.TargetID != uuid.Nil},
+		BeforeState: before,
+		AfterState:  after,
+	}
+	if p, ok := principalFromContext(ctx); ok {
+		params.ActorID = uuid.NullUUID{UUID: p.UserID, Valid: true}
+	}
+	if ip := clientIPFromContext(ctx); ip != "" {
+		params.Ip = sql.NullString{String: ip, Valid: true}
+	}
+	if id := requestIDFromContext(ctx); id != "" {
+		params.RequestID = sql.NullString{String: id, Valid: true}
+	}
+
+	return q.CreateAuditEvent(ctx, params)
+}
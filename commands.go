@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/arglp/chirpy/internal/auth"
+	"github.com/arglp/chirpy/internal/config"
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/arglp/chirpy/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) runCommand(args []string) error {
+	switch args[0] {
+	case "password-report":
+		return cfg.commandPasswordReport()
+	case "migrate":
+		return cfg.commandMigrate(args[1:])
+	case "user":
+		return cfg.commandUser(args[1:])
+	case "token":
+		return cfg.commandToken(args[1:])
+	case "chirp":
+		return cfg.commandChirp(args[1:])
+	case "seed":
+		return cfg.commandSeed(args[1:])
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+func (cfg *apiConfig) commandPasswordReport() error {
+	users, err := cfg.dbQueries.GetUserPasswordHashes(context.Background())
+	if err != nil {
+		return err
+	}
+
+	outdated := 0
+	unreadable := 0
+	for _, user := range users {
+		rehash, err := auth.PasswordNeedsRehash(user.HashedPassword, cfg.passwordParams)
+		if err != nil {
+			unreadable++
+			continue
+		}
+		if rehash {
+			outdated++
+		}
+	}
+
+	fmt.Printf("users: %d\n", len(users))
+	fmt.Printf("on outdated argon2id parameters: %d\n", outdated)
+	fmt.Printf("unreadable password hashes: %d\n", unreadable)
+	return nil
+}
+
+// lookupUser finds a user by ID or, failing that, by email.
+func (cfg *apiConfig) lookupUser(ctx context.Context, ref string) (database.User, error) {
+	if ref == "" {
+		return database.User{}, errors.New("--user is required")
+	}
+	if id, err := uuid.Parse(ref); err == nil {
+		return cfg.dbQueries.GetUserByID(ctx, id)
+	}
+	return cfg.dbQueries.GetUser(ctx, ref)
+}
+
+func (cfg *apiConfig) commandUser(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: chirpy user create|promote-admin|set-red")
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("user create", flag.ContinueOnError)
+		email := fs.String("email", "", "email of the new user")
+		password := fs.String("password", "", "password of the new user")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *email == "" || *password == "" {
+			return errors.New("--email and --password are required")
+		}
+		hashedPassword, err := auth.HashPasswordWithParams(*password, cfg.passwordParams)
+		if err != nil {
+			return err
+		}
+		user, err := cfg.dbQueries.CreateUser(ctx, database.CreateUserParams{
+			Email:          *email,
+			HashedPassword: hashedPassword,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created user %s (%s)\n", user.ID, user.Email)
+		return nil
+	case "promote-admin":
+		fs := flag.NewFlagSet("user promote-admin", flag.ContinueOnError)
+		ref := fs.String("user", "", "ID or email of the user")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		user, err := cfg.lookupUser(ctx, *ref)
+		if err != nil {
+			return err
+		}
+		promoted, err := cfg.dbQueries.SetUserRole(ctx, database.SetUserRoleParams{
+			ID:   user.ID,
+			Role: roleAdmin,
+		})
+		if err != nil {
+			return err
+		}
+		cfg.recordRoleChange(ctx, user, promoted)
+		user = promoted
+		fmt.Printf("user %s (%s) is now an admin\n", user.ID, user.Email)
+		return nil
+	case "set-red":
+		fs := flag.NewFlagSet("user set-red", flag.ContinueOnError)
+		ref := fs.String("user", "", "ID or email of the user")
+		off := fs.Bool("off", false, "remove Chirpy Red instead of granting it")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		user, err := cfg.lookupUser(ctx, *ref)
+		if err != nil {
+			return err
+		}
+		// Going through the subscription keeps the grant subject to expiry
+		// like one bought through Polka.
+		event := "user.upgraded"
+		if *off {
+			event = "user.downgraded"
+		}
+		err = cfg.applySubscriptionEvent(ctx, user.ID, event, time.Time{})
+		if errors.Is(err, errSubscriptionNotFound) {
+			return fmt.Errorf("user %s has no Chirpy Red subscription", user.Email)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("user %s (%s) chirpy red: %t\n", user.ID, user.Email, !*off)
+		return nil
+	default:
+		return fmt.Errorf("unknown user command %q", args[0])
+	}
+}
+
+func (cfg *apiConfig) commandToken(args []string) error {
+	if len(args) == 0 || args[0] != "revoke-all" {
+		return errors.New("usage: chirpy token revoke-all --user <id|email>")
+	}
+	ctx := context.Background()
+
+	fs := flag.NewFlagSet("token revoke-all", flag.ContinueOnError)
+	ref := fs.String("user", "", "ID or email of the user")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	user, err := cfg.lookupUser(ctx, *ref)
+	if err != nil {
+		return err
+	}
+
+	refreshTokens, err := cfg.dbQueries.RevokeRefreshTokensForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	personalTokens, err := cfg.dbQueries.RevokePersonalAccessTokensForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("revoked %d refresh tokens and %d personal access tokens for %s\n", refreshTokens, personalTokens, user.Email)
+	return nil
+}
+
+func (cfg *apiConfig) commandChirp(args []string) error {
+	if len(args) != 2 || args[0] != "delete" {
+		return errors.New("usage: chirpy chirp delete <chirp-id>")
+	}
+	ctx := context.Background()
+
+	chirpID, err := uuid.Parse(args[1])
+	if err != nil {
+		return fmt.Errorf("invalid chirp ID: %w", err)
+	}
+	chirp, err := cfg.dbQueries.GetChirp(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+	err = cfg.dbQueries.DeleteChirpByID(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+	cfg.recordAudit(ctx, auditEvent{
+		Action:     auditChirpDeleted,
+		TargetType: auditTargetChirp,
+		TargetID:   chirp.ID,
+		Before:     transcribeChirp(chirp),
+	})
+	cfg.emitWebhookEvent(ctx, chirp.UserID, webhooks.EventChirpDeleted, transcribeChirp(chirp))
+	fmt.Printf("deleted chirp %s\n", chirp.ID)
+	return nil
+}
+
+// commandSeed fills a development database with users and chirps. Seeded
+// emails carry a random suffix so the command can be run more than once.
+// Every seeded user shares a known password, so it refuses to run outside
+// the dev platform.
+func (cfg *apiConfig) commandSeed(args []string) error {
+	if cfg.platform != config.PlatformDev {
+		return fmt.Errorf("seed only runs on the %s platform", config.PlatformDev)
+	}
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	users := fs.Int("users", 10, "number of users to create")
+	chirps := fs.Int("chirps", 5, "number of chirps per user")
+	password := fs.String("password", "password", "password for every seeded user")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *users < 0 || *chirps < 0 {
+		return errors.New("--users and --chirps must not be negative")
+	}
+	ctx := context.Background()
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	// Every seeded user shares the password, so hash it once.
+	hashedPassword, err := auth.HashPasswordWithParams(*password, cfg.passwordParams)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < *users; i++ {
+		user, err := cfg.dbQueries.CreateUser(ctx, database.CreateUserParams{
+			Email:          fmt.Sprintf("seed-%s-%d@example.com", hex.EncodeToString(suffix), i+1),
+			HashedPassword: hashedPassword,
+		})
+		if err != nil {
+			return err
+		}
+		for j := 0; j < *chirps; j++ {
+			_, err = cfg.dbQueries.CreateChirp(ctx, database.CreateChirpParams{
+				Body:   fmt.Sprintf("Seed chirp %d from %s", j+1, user.Email),
+				UserID: user.ID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	fmt.Printf("seeded %d users with %d chirps each\n", *users, *chirps)
+	return nil
+}
@@ -1,18 +1,44 @@
 package main
 
 import(
+	"database/sql"
+	"io/fs"
+	"sync"
 	"sync/atomic"
 	"net/http"
+	"time"
 
+	"github.com/alexedwards/argon2id"
+	"github.com/arglp/chirpy/internal/config"
 	"github.com/arglp/chirpy/internal/database"
+	"github.com/arglp/chirpy/internal/mailer"
+	"github.com/arglp/chirpy/internal/webhooks"
 )
 
 type apiConfig struct {
 	fileserverHits atomic.Int32
-	dbQueries *database.Queries
+	db *sql.DB
+	dbDriver string
+	dbQueries database.Querier
+	migrations fs.FS
+	heartbeats *workerHeartbeats
 	platform string	
 	secret string
 	polkaKey string
+	polkaWebhookSecret string
+	passwordParams *argon2id.Params
+	mailer mailer.Mailer
+	magicLinkURL string
+	webhookSender webhooks.Sender
+	metrics *serverMetrics
+	accessTokenTTL time.Duration
+	refreshTokenTTL time.Duration
+	magicLinkTTL time.Duration
+	accountDeletionGracePeriod time.Duration
+	dataExportTTL time.Duration
+	// background tracks work handed off by requests, such as sending mail,
+	// so shutdown can wait for it.
+	background sync.WaitGroup
 }
 
 func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
@@ -20,4 +46,19 @@ func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
 		cfg.fileserverHits.Add(1)
 		next.ServeHTTP(w, r)
 	})
-}
\ No newline at end of file
+}
+
+// newMailer sends mail through SMTP when an address is configured and falls
+// back to logging messages otherwise. Message bodies hold login links and
+// are only logged on the dev platform.
+func newMailer(smtp config.SMTP, platform string) mailer.Mailer {
+	if smtp.Addr == "" {
+		return mailer.LogMailer{IncludeBody: platform == config.PlatformDev}
+	}
+	return mailer.SMTPMailer{
+		Addr:     smtp.Addr,
+		From:     smtp.From,
+		Username: smtp.Username,
+		Password: smtp.Password,
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/arglp/chirpy/internal/auth"
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type DataExport struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Status      string     `json:"status"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+func transcribeDataExport(dE database.DataExport) DataExport {
+	export := DataExport{
+		ID:        dE.ID,
+		CreatedAt: dE.CreatedAt,
+		Status:    dE.Status,
+	}
+	if dE.CompletedAt.Valid {
+		export.CompletedAt = &dE.CompletedAt.Time
+	}
+	if dE.ExpiresAt.Valid {
+		export.ExpiresAt = &dE.ExpiresAt.Time
+	}
+	return export
+}
+
+// handlerDeleteAccount schedules the caller's account for deletion after
+// the grace period. The password is asked for again so that a stolen
+// access token alone can't delete the account, and a wrong one counts as a
+// failed login so guessing shows up in the metrics.
+func (cfg *apiConfig) handlerDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Password string `json:"password"`
+	}
+
+	caller, _ := principalFromContext(r.Context())
+
+	params := parameters{}
+	err := json.NewDecoder(r.Body).Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, "Couldn't decode parameters")
+		return
+	}
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), caller.UserID)
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	ok, err := auth.CheckPasswordHash(params.Password, user.HashedPassword)
+	if err != nil || !ok {
+		cfg.metrics.failedLogins.Inc()
+		respondWithError(w, 403, "Incorrect password")
+		return
+	}
+	if user.DeletionScheduledAt.Valid {
+		respondWithJson(w, 202, transcribeUser(user))
+		return
+	}
+
+	user, err = cfg.scheduleAccountDeletion(r.Context(), user)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't schedule account deletion")
+		return
+	}
+	respondWithJson(w, 202, transcribeUser(user))
+}
+
+func (cfg *apiConfig) handlerCancelAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	caller, _ := principalFromContext(r.Context())
+
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), caller.UserID)
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	if !user.DeletionScheduledAt.Valid {
+		respondWithError(w, 409, "Account isn't scheduled for deletion")
+		return
+	}
+	user, err = cfg.dbQueries.ScheduleUserDeletion(r.Context(), database.ScheduleUserDeletionParams{ID: user.ID})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't cancel account deletion")
+		return
+	}
+	cfg.recordAudit(r.Context(), auditEvent{
+		Action:     auditUserDeletionCancelled,
+		TargetType: auditTargetUser,
+		TargetID:   user.ID,
+	})
+	respondWithJson(w, 200, transcribeUser(user))
+}
+
+// handlerRequestDataExport queues an export of the caller's data. Asking
+// again while one is being built returns that one instead.
+func (cfg *apiConfig) handlerRequestDataExport(w http.ResponseWriter, r *http.Request) {
+	caller, _ := principalFromContext(r.Context())
+
+	latest, err := cfg.dbQueries.GetLatestDataExportForUser(r.Context(), caller.UserID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 500, "Couldn't get data export")
+		return
+	}
+	if err == nil && (latest.Status == dataExportPending || latest.Status == dataExportRunning) {
+		respondWithJson(w, 202, transcribeDataExport(latest))
+		return
+	}
+
+	export, err := cfg.dbQueries.CreateDataExport(r.Context(), caller.UserID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't request data export")
+		return
+	}
+	respondWithJson(w, 202, transcribeDataExport(export))
+}
+
+// handlerGetDataExport serves the caller's latest data export as a ZIP once
+// it is ready, and its status while it is still being built.
+func (cfg *apiConfig) handlerGetDataExport(w http.ResponseWriter, r *http.Request) {
+	caller, _ := principalFromContext(r.Context())
+
+	export, err := cfg.dbQueries.GetLatestDataExportForUser(r.Context(), caller.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "No data export requested")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get data export")
+		return
+	}
+
+	switch export.Status {
+	case dataExportPending, dataExportRunning:
+		respondWithJson(w, 202, transcribeDataExport(export))
+	case dataExportFailed:
+		respondWithError(w, 500, "Data export failed, request a new one")
+	default:
+		if export.ExpiresAt.Valid && !time.Now().Before(export.ExpiresAt.Time) {
+			respondWithError(w, 404, "Data export expired, request a new one")
+			return
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="chirpy-export.zip"`)
+		w.Header().Set("Content-Length", strconv.Itoa(len(export.Archive)))
+		w.WriteHeader(200)
+		w.Write(export.Archive)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type AuditEvent struct {
+	ID         uuid.UUID       `json:"id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	ActorID    *uuid.UUID      `json:"actor_id"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   *uuid.UUID      `json:"target_id"`
+	IP         *string         `json:"ip"`
+	RequestID  *string         `json:"request_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+}
+
+func transcribeAuditEvent(dE database.AuditEvent) AuditEvent {
+	event := AuditEvent{
+		ID:         dE.ID,
+		CreatedAt:  dE.CreatedAt,
+		Action:     dE.Action,
+		TargetType: dE.TargetType,
+		Before:     dE.BeforeState,
+		After:      dE.AfterState,
+	}
+	if dE.ActorID.Valid {
+		event.ActorID = &dE.ActorID.UUID
+	}
+	if dE.TargetID.Valid {
+		event.TargetID = &dE.TargetID.UUID
+	}
+	if dE.Ip.Valid {
+		event.IP = &dE.Ip.String
+	}
+	if dE.RequestID.Valid {
+		event.RequestID = &dE.RequestID.String
+	}
+	return event
+}
+
+// handlerListAuditEvents returns audit events newest first, optionally
+// filtered by actor_id, target_id and a since/until range of RFC 3339
+// times. since is inclusive and until exclusive.
+func (cfg *apiConfig) handlerListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	limit, offset, ok := parsePagination(r)
+	if !ok {
+		respondWithError(w, 400, "invalid limit or offset")
+		return
+	}
+	params := database.ListAuditEventsParams{Limit: limit, Offset: offset}
+	query := r.URL.Query()
+	if v := query.Get("actor_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			respondWithError(w, 400, "invalid actor_id")
+			return
+		}
+		params.ActorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	if v := query.Get("target_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			respondWithError(w, 400, "invalid target_id")
+			return
+		}
+		params.TargetID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	if v := query.Get("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			respondWithError(w, 400, "since must be an RFC 3339 time")
+			return
+		}
+		params.Since = sql.NullTime{Time: since, Valid: true}
+	}
+	if v := query.Get("until"); v != "" {
+		until, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			respondWithError(w, 400, "until must be an RFC 3339 time")
+			return
+		}
+		params.Until = sql.NullTime{Time: until, Valid: true}
+	}
+
+	results, err := cfg.dbQueries.ListAuditEvents(r.Context(), params)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't list audit events")
+		return
+	}
+	events := []AuditEvent{}
+	for _, result := range results {
+		events = append(events, transcribeAuditEvent(result))
+	}
+	respondWithJson(w, 200, events)
+}
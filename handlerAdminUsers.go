@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerSetUserRole(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Role string `json:"role"`
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid user ID")
+		return
+	}
+	var params parameters
+	err = json.NewDecoder(r.Body).Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, "Couldn't decode parameters")
+		return
+	}
+	if _, ok := roleRank[params.Role]; !ok {
+		respondWithError(w, 400, "role must be user, moderator or admin")
+		return
+	}
+	// Keeping admins from changing their own role means the last admin
+	// can't lock everyone out of the admin API.
+	p, _ := principalFromContext(r.Context())
+	if userID == p.UserID {
+		respondWithError(w, 400, "You can't change your own role")
+		return
+	}
+
+	previous, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Couldn't look up user")
+		return
+	}
+	user, err := cfg.dbQueries.SetUserRole(r.Context(), database.SetUserRoleParams{
+		ID:   userID,
+		Role: params.Role,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't update role")
+		return
+	}
+	slog.InfoContext(r.Context(), "user role changed", "user_id", user.ID, "role", user.Role, "changed_by", p.UserID)
+	cfg.recordRoleChange(r.Context(), previous, user)
+	respondWithJson(w, 200, transcribeUser(user))
+}
+
+// bootstrapAdmin promotes the account with the given email to admin, so a
+// fresh deployment has someone who can reach the admin API. The account
+// must already exist: promoting it on sign-up would hand admin to whoever
+// registers the address first.
+func (cfg *apiConfig) bootstrapAdmin(ctx context.Context, email string) error {
+	user, err := cfg.dbQueries.GetUser(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		slog.WarnContext(ctx, "bootstrap admin has no account yet; sign up and restart", "email", email)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.Role == roleAdmin {
+		return nil
+	}
+	promoted, err := cfg.dbQueries.SetUserRole(ctx, database.SetUserRoleParams{
+		ID:   user.ID,
+		Role: roleAdmin,
+	})
+	if err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "promoted bootstrap admin", "user_id", user.ID, "email", email)
+	cfg.recordRoleChange(ctx, user, promoted)
+	return nil
+}
+
+func (cfg *apiConfig) recordRoleChange(ctx context.Context, before, after database.User) {
+	cfg.recordAudit(ctx, auditEvent{
+		Action:     auditUserRoleChanged,
+		TargetType: auditTargetUser,
+		TargetID:   after.ID,
+		Before:     map[string]any{"role": before.Role},
+		After:      map[string]any{"role": after.Role},
+	})
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type InboundWebhook struct {
+	ID         uuid.UUID       `json:"id"`
+	ReceivedAt time.Time       `json:"received_at"`
+	Source     string          `json:"source"`
+	Headers    json.RawMessage `json:"headers"`
+	Body       string          `json:"body"`
+	StatusCode *int32          `json:"status_code"`
+	Outcome    *string         `json:"outcome"`
+	Error      *string         `json:"error"`
+	ReplayOf   *uuid.UUID      `json:"replay_of"`
+}
+
+func transcribeInboundWebhook(dW database.InboundWebhook) InboundWebhook {
+	webhook := InboundWebhook{
+		ID:         dW.ID,
+		ReceivedAt: dW.ReceivedAt,
+		Source:     dW.Source,
+		Headers:    dW.Headers,
+		Body:       dW.Body,
+	}
+	if dW.StatusCode.Valid {
+		webhook.StatusCode = &dW.StatusCode.Int32
+	}
+	if dW.Outcome.Valid {
+		webhook.Outcome = &dW.Outcome.String
+	}
+	if dW.Error.Valid {
+		webhook.Error = &dW.Error.String
+	}
+	if dW.ReplayOf.Valid {
+		webhook.ReplayOf = &dW.ReplayOf.UUID
+	}
+	return webhook
+}
+
+// parsePagination reads the limit and offset query parameters, defaulting
+// to the first 50 results and allowing at most 200 per page.
+func parsePagination(r *http.Request) (int32, int32, bool) {
+	limit := int64(50)
+	offset := int64(0)
+	var err error
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.ParseInt(v, 10, 32)
+		if err != nil || limit < 1 || limit > 200 {
+			return 0, 0, false
+		}
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		offset, err = strconv.ParseInt(v, 10, 32)
+		if err != nil || offset < 0 {
+			return 0, 0, false
+		}
+	}
+	return int32(limit), int32(offset), true
+}
+
+func (cfg *apiConfig) handlerListInboundWebhooks(w http.ResponseWriter, r *http.Request) {
+	limit, offset, ok := parsePagination(r)
+	if !ok {
+		respondWithError(w, 400, "invalid limit or offset")
+		return
+	}
+	outcome := sql.NullString{}
+	if v := r.URL.Query().Get("outcome"); v != "" {
+		outcome = sql.NullString{String: v, Valid: true}
+	}
+
+	results, err := cfg.dbQueries.ListInboundWebhooks(context.Background(), database.ListInboundWebhooksParams{
+		Outcome: outcome,
+		Limit:   limit,
+		Offset:  offset,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't list webhooks")
+		return
+	}
+	webhooks := []InboundWebhook{}
+	for _, result := range results {
+		webhooks = append(webhooks, transcribeInboundWebhook(result))
+	}
+	respondWithJson(w, 200, webhooks)
+}
+
+func (cfg *apiConfig) handlerGetInboundWebhook(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(r.PathValue("eventID"))
+	if err != nil {
+		respondWithError(w, 400, "invalid event id")
+		return
+	}
+	webhook, err := cfg.dbQueries.GetInboundWebhook(context.Background(), eventID)
+	if err != nil {
+		respondWithError(w, 404, "couldn't find event")
+		return
+	}
+	respondWithJson(w, 200, transcribeInboundWebhook(webhook))
+}
+
+// handlerReplayInboundWebhook runs a logged Polka event through the same
+// processing as the webhook endpoint. Only the body is replayed: the stored
+// headers have the credentials redacted and can't be checked again. Events
+// are logged before they are authenticated, so only events that got past
+// authentication may be replayed; rejected events and ones that were never
+// finished are refused. The replay is logged as a new event pointing back at
+// the original.
+func (cfg *apiConfig) handlerReplayInboundWebhook(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(r.PathValue("eventID"))
+	if err != nil {
+		respondWithError(w, 400, "invalid event id")
+		return
+	}
+	original, err := cfg.dbQueries.GetInboundWebhook(context.Background(), eventID)
+	if err != nil {
+		respondWithError(w, 404, "couldn't find event")
+		return
+	}
+	if original.Source != "polka" {
+		respondWithError(w, 400, "can't replay events from "+original.Source)
+		return
+	}
+	if !original.Outcome.Valid || original.Outcome.String == webhookOutcomeRejected {
+		respondWithError(w, 409, "can't replay an event that wasn't authenticated")
+		return
+	}
+
+	replay, err := cfg.dbQueries.CreateInboundWebhook(context.Background(), database.CreateInboundWebhookParams{
+		Source:   original.Source,
+		Headers:  original.Headers,
+		Body:     original.Body,
+		ReplayOf: uuid.NullUUID{UUID: original.ID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't record replay")
+		return
+	}
+
+	replayID := replay.ID
+	result := cfg.processPolkaEvent(r.Context(), []byte(original.Body))
+	replay = cfg.finishInboundWebhook(r.Context(), replayID, result)
+	cfg.recordAudit(r.Context(), auditEvent{
+		Action:     auditWebhookReplayed,
+		TargetType: auditTargetInboundWebhook,
+		TargetID:   original.ID,
+		Before:     map[string]any{"outcome": original.Outcome.String},
+		After:      map[string]any{"replay_id": replayID, "outcome": result.Outcome},
+	})
+	respondWithJson(w, 200, transcribeInboundWebhook(replay))
+}
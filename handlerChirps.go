@@ -6,8 +6,9 @@ import (
 	"net/http"
 	"time"
 
-	"github.com/arglp/chirpy/internal/auth"
 	"github.com/arglp/chirpy/internal/database"
+	"github.com/arglp/chirpy/internal/entitlements"
+	"github.com/arglp/chirpy/internal/webhooks"
 	"github.com/google/uuid"
 )
 
@@ -36,32 +37,44 @@ func (cfg *apiConfig) handlerPostChirps(w http.ResponseWriter, r *http.Request)
 		Body string `json:"body"`
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
-		if err != nil {
-			respondWithError(w, 400, "Something went wrong")
-			return
-		}
-
-	userID, err := auth.ValidateJWT(token, cfg.secret)
-	if err != nil {
-		respondWithError(w, 401, "unauthorized")
-		return
-	}
+	caller, _ := principalFromContext(r.Context())
+	userID := caller.UserID
 	
 	decoder := json.NewDecoder(r.Body)
 	params := parameters{}
 
-	err = decoder.Decode(&params)
+	err := decoder.Decode(&params)
 	if err != nil {
 		respondWithError(w, 400, "Something went wrong")
 		return
 	}
 	
-	if len(params.Body) > 140 {
+	user, err := cfg.dbQueries.GetUserByID(context.Background(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get user")
+		return
+	}
+	userEntitlements := entitlements.For(entitlements.PlanFor(user.IsChirpyRed))
+
+	if len(params.Body) > userEntitlements.MaxChirpLength {
 		respondWithError(w, 400, "Chirp is too long")
 		return
 	}
 
+	recentChirps, err := cfg.dbQueries.CountChirpsByUserSince(context.Background(), database.CountChirpsByUserSinceParams{
+		UserID: userID,
+		CreatedAt: time.Now().Add(-time.Hour),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't check chirp rate")
+		return
+	}
+	if recentChirps >= int64(userEntitlements.ChirpsPerHour) {
+		w.Header().Set("Retry-After", "3600")
+		respondWithError(w, 429, "Chirp rate limit reached")
+		return
+	}
+
 	chirp, err := cfg.dbQueries.CreateChirp(context.Background(), database.CreateChirpParams{
 		Body: replaceProfaneWords(params.Body),
 		UserID: userID})
@@ -70,12 +83,15 @@ func (cfg *apiConfig) handlerPostChirps(w http.ResponseWriter, r *http.Request)
 		respondWithError(w, 400, "Coudn't create chirp")
 		return
 	}
+	cfg.metrics.chirpsCreated.Inc()
+	cfg.emitWebhookEvent(context.Background(), chirp.UserID, webhooks.EventChirpCreated, transcribeChirp(chirp))
 	respondWithJson(w, 201, transcribeChirp(chirp))	
 }
 
 func (cfg *apiConfig) handlerGetChirps(w http.ResponseWriter, r *http.Request) {
 	
-	results, err := cfg.dbQueries.GetChirps(context.Background())
+	caller, ok := principalFromContext(r.Context())
+	results, err := cfg.dbQueries.GetChirps(context.Background(), uuid.NullUUID{UUID: caller.UserID, Valid: ok})
 	if err != nil {
 		respondWithError(w, 400, "Something went wrong")
 		return
@@ -93,6 +109,7 @@ func (cfg *apiConfig) handlerGetChirpByID(w http.ResponseWriter, r *http.Request
 	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
 	if err != nil {
 		respondWithError(w, 400, "Something went wrong")
+		return
 	}
 	
 	chirp, err := cfg.dbQueries.GetChirp(context.Background(), chirpID)
@@ -100,22 +117,33 @@ func (cfg *apiConfig) handlerGetChirpByID(w http.ResponseWriter, r *http.Request
 		respondWithError(w, 404, "couldn't find chirp")
 		return
 	}
+	// Chirps hidden by a moderator, and those of shadowbanned users, stay
+	// visible to their author only. A block hides chirps both ways.
+	caller, ok := principalFromContext(r.Context())
+	if caller.UserID != chirp.UserID {
+		author, err := cfg.dbQueries.GetUserByID(context.Background(), chirp.UserID)
+		if err != nil || chirp.HiddenAt.Valid || author.Shadowbanned {
+			respondWithError(w, 404, "couldn't find chirp")
+			return
+		}
+	}
+	if ok && caller.UserID != chirp.UserID {
+		blocks, err := cfg.dbQueries.CountBlocksBetween(context.Background(), database.CountBlocksBetweenParams{
+			UserID:      caller.UserID,
+			OtherUserID: chirp.UserID,
+		})
+		if err != nil || blocks > 0 {
+			respondWithError(w, 404, "couldn't find chirp")
+			return
+		}
+	}
 
 	respondWithJson(w, 200, transcribeChirp(chirp))	
 }
 
 func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request) {
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, 401, "authorization not found")
-		return
-	}
-
-	userID, err := auth.ValidateJWT(token, cfg.secret)
-	if err != nil {
-		respondWithError(w, 403, "couldn't find access token")
-		return
-	}
+	caller, _ := principalFromContext(r.Context())
+	userID := caller.UserID
 
 	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
 	if err != nil {
@@ -137,8 +165,74 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request)
 	err = cfg.dbQueries.DeleteChirpByID(context.Background(), chirpID)
 	if err != nil {
 		respondWithError(w, 404, "couldn't delete chirp")
+		return
 	}
+	cfg.recordAudit(r.Context(), auditEvent{
+		Action:     auditChirpDeleted,
+		TargetType: auditTargetChirp,
+		TargetID:   chirp.ID,
+		Before:     transcribeChirp(chirp),
+	})
+	cfg.emitWebhookEvent(context.Background(), chirp.UserID, webhooks.EventChirpDeleted, transcribeChirp(chirp))
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.WriteHeader(204)
 	w.Write([]byte("OK\n"))
-}
\ No newline at end of file
+}
+
+func (cfg *apiConfig) handlerUpdateChirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	caller, _ := principalFromContext(r.Context())
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, 400, "Something went wrong")
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, "Something went wrong")
+		return
+	}
+
+	chirp, err := cfg.dbQueries.GetChirp(context.Background(), chirpID)
+	if err != nil {
+		respondWithError(w, 404, "couldn't find chirp")
+		return
+	}
+	if caller.UserID != chirp.UserID {
+		respondWithError(w, 403, "not user of chirp")
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(context.Background(), caller.UserID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get user")
+		return
+	}
+	userEntitlements := entitlements.For(entitlements.PlanFor(user.IsChirpyRed))
+	if !userEntitlements.CanEditChirps {
+		respondWithError(w, 403, "editing chirps requires Chirpy Red")
+		return
+	}
+	if len(params.Body) > userEntitlements.MaxChirpLength {
+		respondWithError(w, 400, "Chirp is too long")
+		return
+	}
+
+	chirp, err = cfg.dbQueries.UpdateChirpBody(context.Background(), database.UpdateChirpBodyParams{
+		Body: replaceProfaneWords(params.Body),
+		ID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't update chirp")
+		return
+	}
+	cfg.emitWebhookEvent(context.Background(), chirp.UserID, webhooks.EventChirpUpdated, transcribeChirp(chirp))
+	respondWithJson(w, 200, transcribeChirp(chirp))
+}
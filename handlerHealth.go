@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	healthCheckTimeout = 2 * time.Second
+
+	// A worker counts as stalled once it has missed this many ticks.
+	workerStallTicks = 3
+
+	workerSubscriptionExpiry = "subscription_expiry"
+	workerWebhookDelivery    = "webhook_delivery"
+	workerAccountPurge       = "account_purge"
+	workerDataExport         = "data_export"
+)
+
+// errNoDatabase fails the checks that need a connection when the server runs
+// on a store without one, as the handler tests do.
+var errNoDatabase = errors.New("no database connection")
+
+type workerStatus struct {
+	interval time.Duration
+	lastRun  time.Time
+	lastErr  error
+}
+
+// workerHeartbeats records when each background worker last completed a run,
+// so readiness can tell a stuck or crashed worker from a healthy one.
+type workerHeartbeats struct {
+	mu      sync.Mutex
+	workers map[string]workerStatus
+}
+
+func newWorkerHeartbeats() *workerHeartbeats {
+	return &workerHeartbeats{workers: map[string]workerStatus{}}
+}
+
+// register adds a worker as of now, giving it one stall window to report in.
+func (h *workerHeartbeats) register(name string, interval time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.workers[name] = workerStatus{interval: interval, lastRun: time.Now()}
+}
+
+// beat records a finished run of a worker along with its outcome.
+func (h *workerHeartbeats) beat(name string, err error) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	status := h.workers[name]
+	status.lastRun = time.Now()
+	status.lastErr = err
+	h.workers[name] = status
+}
+
+// progress records that a worker is still making headway through a run that
+// may take longer than its stall window, without touching the outcome of the
+// last finished run.
+func (h *workerHeartbeats) progress(name string) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	status := h.workers[name]
+	status.lastRun = time.Now()
+	h.workers[name] = status
+}
+
+func (h *workerHeartbeats) check(name string, now time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	status := h.workers[name]
+	if now.Sub(status.lastRun) > workerStallTicks*status.interval {
+		return fmt.Errorf("no run since %s", status.lastRun.UTC().Format(time.RFC3339))
+	}
+	if status.lastErr != nil {
+		return fmt.Errorf("last run failed: %w", status.lastErr)
+	}
+	return nil
+}
+
+func (h *workerHeartbeats) names() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	names := make([]string, 0, len(h.workers))
+	for name := range h.workers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type HealthCheck struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Readiness struct {
+	Status string        `json:"status"`
+	Checks []HealthCheck `json:"checks"`
+}
+
+func (cfg *apiConfig) handlerLiveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(200)
+	w.Write([]byte("OK\n"))
+}
+
+// handlerReadiness reports whether the server can take traffic: the database
+// answers, every migration has been applied and the background workers are
+// running. It answers 503 with the same breakdown when any check fails.
+func (cfg *apiConfig) handlerReadiness(w http.ResponseWriter, r *http.Request) {
+	checks := []HealthCheck{
+		runHealthCheck(r.Context(), "database", cfg.checkDatabase),
+		runHealthCheck(r.Context(), "migrations", cfg.checkMigrations),
+	}
+	now := time.Now()
+	for _, name := range cfg.heartbeats.names() {
+		checks = append(checks, runHealthCheck(r.Context(), "worker:"+name, func(ctx context.Context) error {
+			return cfg.heartbeats.check(name, now)
+		}))
+	}
+
+	readiness := Readiness{Status: "ok", Checks: checks}
+	code := 200
+	for _, check := range checks {
+		if check.Status != "ok" {
+			readiness.Status = "unavailable"
+			code = 503
+		}
+	}
+	respondWithJson(w, code, readiness)
+}
+
+func runHealthCheck(ctx context.Context, name string, check func(context.Context) error) HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := HealthCheck{
+		Name:      name,
+		Status:    "ok",
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = "fail"
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func (cfg *apiConfig) checkDatabase(ctx context.Context) error {
+	if cfg.db == nil {
+		return errNoDatabase
+	}
+	return cfg.db.PingContext(ctx)
+}
+
+// checkMigrations compares the versions goose has recorded as applied with
+// the migrations embedded in the binary.
+func (cfg *apiConfig) checkMigrations(ctx context.Context) error {
+	if cfg.db == nil {
+		return errNoDatabase
+	}
+	versions, err := migrationVersions(cfg.migrations)
+	if err != nil {
+		return err
+	}
+
+	rows, err := cfg.db.QueryContext(ctx, "SELECT version_id FROM goose_db_version WHERE is_applied")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	applied := map[int64]bool{}
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			return err
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, version := range versions {
+		if !applied[version] {
+			pending = append(pending, strconv.FormatInt(version, 10))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("pending migrations: %s", strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// migrationVersions returns the versions of the goose migrations in fsys,
+// taken from the numeric prefix of each .sql file name.
+func migrationVersions(fsys fs.FS) ([]int64, error) {
+	if fsys == nil {
+		return nil, errors.New("no migrations configured")
+	}
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	var versions []int64
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		prefix, _, _ := strings.Cut(entry.Name(), "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: no version prefix", entry.Name())
+		}
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions, nil
+}
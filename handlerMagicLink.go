@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/arglp/chirpy/internal/auth"
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/arglp/chirpy/internal/mailer"
+)
+
+// handlerMagicLink emails a single-use login link. It answers 202 whether or
+// not the email belongs to a user, so it can't be used to probe accounts.
+// The link is made and sent in the background, so the response doesn't
+// take longer when there is an account to mail either.
+func (cfg *apiConfig) handlerMagicLink(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Email string `json:"email"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, "Something went wrong")
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUser(context.Background(), params.Email)
+	if err == nil {
+		ctx := context.WithoutCancel(r.Context())
+		cfg.background.Add(1)
+		go func() {
+			defer cfg.background.Done()
+			err := cfg.sendMagicLink(ctx, user)
+			if err != nil {
+				slog.ErrorContext(ctx, "couldn't send login link", "user_id", user.ID, "error", err)
+			}
+		}()
+	}
+	w.WriteHeader(202)
+}
+
+func (cfg *apiConfig) sendMagicLink(ctx context.Context, user database.User) error {
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return err
+	}
+	err = cfg.dbQueries.CreateMagicLinkToken(ctx, database.CreateMagicLinkTokenParams{
+		TokenHash: auth.HashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(cfg.magicLinkTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	link, err := url.Parse(cfg.magicLinkURL)
+	if err != nil {
+		return err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	return cfg.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your Chirpy login link",
+		Body: fmt.Sprintf("Use this link to log in to Chirpy. It expires in %s and works once.\n\n%s\n",
+			describeDuration(cfg.magicLinkTTL), link.String()),
+	})
+}
+
+// describeDuration spells out d for a message to a user, in whole hours or
+// minutes where it divides evenly.
+func describeDuration(d time.Duration) string {
+	plural := func(n int64, unit string) string {
+		if n == 1 {
+			return "1 " + unit
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return plural(int64(d/time.Hour), "hour")
+	case d >= time.Minute && d%time.Minute == 0:
+		return plural(int64(d/time.Minute), "minute")
+	}
+	return d.String()
+}
+
+func (cfg *apiConfig) handlerRedeemMagicLink(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Token string `json:"token"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, "Something went wrong")
+		return
+	}
+
+	magicLink, err := cfg.dbQueries.ConsumeMagicLinkToken(context.Background(), auth.HashToken(params.Token))
+	if err != nil {
+		cfg.metrics.failedLogins.Inc()
+		respondWithError(w, 401, "invalid or used login token")
+		return
+	}
+	if time.Now().After(magicLink.ExpiresAt) {
+		cfg.metrics.failedLogins.Inc()
+		respondWithError(w, 401, "login token expired")
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(context.Background(), magicLink.UserID)
+	if err != nil {
+		respondWithError(w, 401, "couldn't find user")
+		return
+	}
+	if respondIfSuspended(w, user) {
+		return
+	}
+	cfg.respondWithSession(w, user, "magic_link")
+}
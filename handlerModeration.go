@@ -0,0 +1,720 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/arglp/chirpy/internal/mailer"
+	"github.com/google/uuid"
+)
+
+const (
+	reportOpen     = "open"
+	reportClaimed  = "claimed"
+	reportResolved = "resolved"
+
+	actionHideChirp = "hide_chirp"
+	actionWarn      = "warn"
+	actionSuspend   = "suspend"
+	actionDismiss   = "dismiss"
+
+	actionUnsuspend   = "unsuspend"
+	actionShadowban   = "shadowban"
+	actionUnshadowban = "unshadowban"
+
+	maxReportDetailsLength = 1000
+)
+
+var reportReasons = []string{"spam", "harassment", "hate", "violence", "other"}
+
+// errReportNotClaimed means the report stopped being claimed by the
+// resolving moderator between the check and the resolution.
+var errReportNotClaimed = errors.New("report is not claimed by the moderator")
+
+type Report struct {
+	ID           uuid.UUID          `json:"id"`
+	CreatedAt    time.Time          `json:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+	ReporterID   uuid.UUID          `json:"reporter_id"`
+	TargetUserID uuid.UUID          `json:"target_user_id"`
+	ChirpID      *uuid.UUID         `json:"chirp_id"`
+	ChirpBody    *string            `json:"chirp_body"`
+	Reason       string             `json:"reason"`
+	Details      string             `json:"details"`
+	Status       string             `json:"status"`
+	ClaimedBy    *uuid.UUID         `json:"claimed_by"`
+	ClaimedAt    *time.Time         `json:"claimed_at"`
+	Resolution   *string            `json:"resolution"`
+	ResolvedAt   *time.Time         `json:"resolved_at"`
+	Actions      []ModerationAction `json:"actions,omitempty"`
+}
+
+func transcribeReport(dR database.Report) Report {
+	report := Report{
+		ID:           dR.ID,
+		CreatedAt:    dR.CreatedAt,
+		UpdatedAt:    dR.UpdatedAt,
+		ReporterID:   dR.ReporterID,
+		TargetUserID: dR.TargetUserID,
+		Reason:       dR.Reason,
+		Details:      dR.Details,
+		Status:       dR.Status,
+	}
+	if dR.ChirpID.Valid {
+		report.ChirpID = &dR.ChirpID.UUID
+	}
+	if dR.ChirpBody.Valid {
+		report.ChirpBody = &dR.ChirpBody.String
+	}
+	if dR.ClaimedBy.Valid {
+		report.ClaimedBy = &dR.ClaimedBy.UUID
+	}
+	if dR.ClaimedAt.Valid {
+		report.ClaimedAt = &dR.ClaimedAt.Time
+	}
+	if dR.Resolution.Valid {
+		report.Resolution = &dR.Resolution.String
+	}
+	if dR.ResolvedAt.Valid {
+		report.ResolvedAt = &dR.ResolvedAt.Time
+	}
+	return report
+}
+
+type ModerationAction struct {
+	ID           uuid.UUID  `json:"id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ModeratorID  *uuid.UUID `json:"moderator_id"`
+	Action       string     `json:"action"`
+	TargetUserID uuid.UUID  `json:"target_user_id"`
+	ChirpID      *uuid.UUID `json:"chirp_id"`
+	Note         string     `json:"note"`
+}
+
+func transcribeModerationAction(dA database.ModerationAction) ModerationAction {
+	action := ModerationAction{
+		ID:           dA.ID,
+		CreatedAt:    dA.CreatedAt,
+		Action:       dA.Action,
+		TargetUserID: dA.TargetUserID,
+		Note:         dA.Note,
+	}
+	if dA.ModeratorID.Valid {
+		action.ModeratorID = &dA.ModeratorID.UUID
+	}
+	if dA.ChirpID.Valid {
+		action.ChirpID = &dA.ChirpID.UUID
+	}
+	return action
+}
+
+type reportParameters struct {
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+}
+
+// decodeReport reads and validates the body of a report, writing the error
+// response itself when it is invalid.
+func decodeReport(w http.ResponseWriter, r *http.Request) (reportParameters, bool) {
+	var params reportParameters
+	err := json.NewDecoder(r.Body).Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, "Couldn't decode parameters")
+		return params, false
+	}
+	if !slices.Contains(reportReasons, params.Reason) {
+		respondWithError(w, 400, "reason must be spam, harassment, hate, violence or other")
+		return params, false
+	}
+	if len(params.Details) > maxReportDetailsLength {
+		respondWithError(w, 400, "details are too long")
+		return params, false
+	}
+	return params, true
+}
+
+func (cfg *apiConfig) handlerReportChirp(w http.ResponseWriter, r *http.Request) {
+	caller, _ := principalFromContext(r.Context())
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid chirp ID")
+		return
+	}
+	params, ok := decodeReport(w, r)
+	if !ok {
+		return
+	}
+	chirp, err := cfg.dbQueries.GetChirp(r.Context(), chirpID)
+	if err != nil || chirp.HiddenAt.Valid {
+		respondWithError(w, 404, "couldn't find chirp")
+		return
+	}
+	if chirp.UserID == caller.UserID {
+		respondWithError(w, 400, "You can't report your own chirp")
+		return
+	}
+
+	// The body is copied so moderators see what was reported even if the
+	// author edits or deletes the chirp afterwards.
+	report, err := cfg.dbQueries.CreateReport(r.Context(), database.CreateReportParams{
+		ReporterID:   caller.UserID,
+		TargetUserID: chirp.UserID,
+		ChirpID:      uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		ChirpBody:    sql.NullString{String: chirp.Body, Valid: true},
+		Reason:       params.Reason,
+		Details:      params.Details,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't create report")
+		return
+	}
+	respondWithJson(w, 201, transcribeReport(report))
+}
+
+func (cfg *apiConfig) handlerReportUser(w http.ResponseWriter, r *http.Request) {
+	caller, _ := principalFromContext(r.Context())
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid user ID")
+		return
+	}
+	params, ok := decodeReport(w, r)
+	if !ok {
+		return
+	}
+	if userID == caller.UserID {
+		respondWithError(w, 400, "You can't report yourself")
+		return
+	}
+	_, err = cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+
+	report, err := cfg.dbQueries.CreateReport(r.Context(), database.CreateReportParams{
+		ReporterID:   caller.UserID,
+		TargetUserID: userID,
+		Reason:       params.Reason,
+		Details:      params.Details,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't create report")
+		return
+	}
+	respondWithJson(w, 201, transcribeReport(report))
+}
+
+func (cfg *apiConfig) handlerListReports(w http.ResponseWriter, r *http.Request) {
+	limit, offset, ok := parsePagination(r)
+	if !ok {
+		respondWithError(w, 400, "invalid limit or offset")
+		return
+	}
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = reportOpen
+	}
+	if status != reportOpen && status != reportClaimed && status != reportResolved {
+		respondWithError(w, 400, "status must be open, claimed or resolved")
+		return
+	}
+
+	results, err := cfg.dbQueries.ListReports(r.Context(), database.ListReportsParams{
+		Status: status,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't list reports")
+		return
+	}
+	reports := []Report{}
+	for _, result := range results {
+		reports = append(reports, transcribeReport(result))
+	}
+	respondWithJson(w, 200, reports)
+}
+
+func (cfg *apiConfig) handlerGetReport(w http.ResponseWriter, r *http.Request) {
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid report ID")
+		return
+	}
+	result, err := cfg.dbQueries.GetReport(r.Context(), reportID)
+	if err != nil {
+		respondWithError(w, 404, "Report not found")
+		return
+	}
+	actions, err := cfg.dbQueries.GetModerationActionsForReport(r.Context(), uuid.NullUUID{UUID: reportID, Valid: true})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get moderation actions")
+		return
+	}
+
+	report := transcribeReport(result)
+	for _, action := range actions {
+		report.Actions = append(report.Actions, transcribeModerationAction(action))
+	}
+	respondWithJson(w, 200, report)
+}
+
+func (cfg *apiConfig) handlerClaimReport(w http.ResponseWriter, r *http.Request) {
+	caller, _ := principalFromContext(r.Context())
+
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid report ID")
+		return
+	}
+	report, err := cfg.dbQueries.GetReport(r.Context(), reportID)
+	if err != nil {
+		respondWithError(w, 404, "Report not found")
+		return
+	}
+	if report.TargetUserID == caller.UserID {
+		respondWithError(w, 403, "You can't handle reports about yourself")
+		return
+	}
+	report, err = cfg.dbQueries.ClaimReport(r.Context(), database.ClaimReportParams{
+		ID:        reportID,
+		ClaimedBy: uuid.NullUUID{UUID: caller.UserID, Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 409, "Report is not open")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Couldn't claim report")
+		return
+	}
+	respondWithJson(w, 200, transcribeReport(report))
+}
+
+// handlerUnclaimReport puts a claimed report back in the queue. The claimer
+// can hand it back, and anyone above them can take back a claim that was
+// left to go stale.
+func (cfg *apiConfig) handlerUnclaimReport(w http.ResponseWriter, r *http.Request) {
+	caller, _ := principalFromContext(r.Context())
+
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid report ID")
+		return
+	}
+	report, err := cfg.dbQueries.GetReport(r.Context(), reportID)
+	if err != nil {
+		respondWithError(w, 404, "Report not found")
+		return
+	}
+	if report.Status != reportClaimed {
+		respondWithError(w, 409, "Report is not claimed")
+		return
+	}
+	if report.ClaimedBy.UUID != caller.UserID {
+		claimer, err := cfg.dbQueries.GetUserByID(r.Context(), report.ClaimedBy.UUID)
+		if err == nil && !cfg.outranks(r.Context(), caller.UserID, claimer) {
+			respondWithError(w, 403, "You can only unclaim reports claimed by you or by users below your role")
+			return
+		}
+	}
+
+	report, err = cfg.dbQueries.UnclaimReport(r.Context(), database.UnclaimReportParams{
+		ID:        report.ID,
+		ClaimedBy: report.ClaimedBy,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 409, "Report is not claimed")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Couldn't unclaim report")
+		return
+	}
+	respondWithJson(w, 200, transcribeReport(report))
+}
+
+func (cfg *apiConfig) handlerResolveReport(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Action     string `json:"action"`
+		Note       string `json:"note"`
+		SuspendFor string `json:"suspend_for"`
+	}
+	caller, _ := principalFromContext(r.Context())
+
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid report ID")
+		return
+	}
+	var params parameters
+	err = json.NewDecoder(r.Body).Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, "Couldn't decode parameters")
+		return
+	}
+
+	report, err := cfg.dbQueries.GetReport(r.Context(), reportID)
+	if err != nil {
+		respondWithError(w, 404, "Report not found")
+		return
+	}
+	if report.Status != reportClaimed || report.ClaimedBy.UUID != caller.UserID {
+		respondWithError(w, 409, "Claim the report before resolving it")
+		return
+	}
+	// Every outcome is a decision about the target, so moderators can't
+	// settle reports about themselves, each other or the admins.
+	target, err := cfg.dbQueries.GetUserByID(r.Context(), report.TargetUserID)
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	if !cfg.outranks(r.Context(), caller.UserID, target) {
+		respondWithError(w, 403, "You can only resolve reports about users below your role")
+		return
+	}
+
+	var suspendFor time.Duration
+	switch params.Action {
+	case actionHideChirp:
+		if !report.ChirpID.Valid {
+			respondWithError(w, 400, "Report has no chirp to hide")
+			return
+		}
+	case actionSuspend:
+		suspendFor, err = time.ParseDuration(params.SuspendFor)
+		if err != nil || suspendFor <= 0 {
+			respondWithError(w, 400, "suspend_for must be a positive duration such as 72h")
+			return
+		}
+	case actionWarn, actionDismiss:
+	default:
+		respondWithError(w, 400, "action must be hide_chirp, warn, suspend or dismiss")
+		return
+	}
+
+	// Mail can't be rolled back, so the warning goes out first: if it
+	// fails nothing has changed and the moderator can try again.
+	if params.Action == actionWarn {
+		err = cfg.warnUser(r.Context(), target, report, params.Note)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "couldn't send warning", "report_id", report.ID, "error", err)
+			respondWithError(w, 500, "Couldn't apply moderation action")
+			return
+		}
+	}
+
+	// The action, its audit entry, the resolution and its record go
+	// together, so a report is never left claimed with its action applied,
+	// or resolved without one.
+	moderatorID := uuid.NullUUID{UUID: caller.UserID, Valid: true}
+	var resolved database.Report
+	var action database.ModerationAction
+	err = cfg.withTx(r.Context(), func(q database.Querier) error {
+		var err error
+		switch params.Action {
+		case actionHideChirp:
+			err = hideChirpAudited(r.Context(), q, report.ChirpID.UUID)
+		case actionSuspend:
+			_, err = restrictAudited(r.Context(), q, report.TargetUserID, auditUserSuspended, func() error {
+				return suspendUser(r.Context(), q, report.TargetUserID, time.Now().Add(suspendFor))
+			})
+		}
+		if err != nil {
+			return err
+		}
+		resolved, err = q.ResolveReport(r.Context(), database.ResolveReportParams{
+			ID:         report.ID,
+			ClaimedBy:  moderatorID,
+			Resolution: sql.NullString{String: params.Action, Valid: true},
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return errReportNotClaimed
+		}
+		if err != nil {
+			return err
+		}
+		action, err = q.CreateModerationAction(r.Context(), database.CreateModerationActionParams{
+			ReportID:     uuid.NullUUID{UUID: report.ID, Valid: true},
+			ModeratorID:  moderatorID,
+			Action:       params.Action,
+			TargetUserID: report.TargetUserID,
+			ChirpID:      report.ChirpID,
+			Note:         params.Note,
+		})
+		return err
+	})
+	if errors.Is(err, errReportNotClaimed) {
+		respondWithError(w, 409, "Claim the report before resolving it")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "couldn't resolve report", "report_id", report.ID, "action", params.Action, "error", err)
+		respondWithError(w, 500, "Couldn't resolve report")
+		return
+	}
+	slog.InfoContext(r.Context(), "report resolved", "report_id", report.ID, "action", params.Action, "moderator_id", caller.UserID, "target_user_id", report.TargetUserID)
+
+	result := transcribeReport(resolved)
+	result.Actions = []ModerationAction{transcribeModerationAction(action)}
+	respondWithJson(w, 200, result)
+}
+
+// warnUser emails the reported user, quoting the chirp when there is one.
+func (cfg *apiConfig) warnUser(ctx context.Context, user database.User, report database.Report, note string) error {
+	body := "A moderator reviewed a report about your Chirpy account and is issuing a warning.\n"
+	if report.ChirpBody.Valid {
+		body += fmt.Sprintf("\nThe report was about this chirp:\n\n    %s\n", report.ChirpBody.String)
+	}
+	if note != "" {
+		body += "\nModerator note: " + note + "\n"
+	}
+	return cfg.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "A warning about your Chirpy account",
+		Body:    body,
+	})
+}
+
+// suspendUser suspends the user until the given time and signs them out
+// everywhere by revoking their refresh tokens and personal access tokens.
+func suspendUser(ctx context.Context, q database.Querier, userID uuid.UUID, until time.Time) error {
+	_, err := q.SuspendUser(ctx, database.SuspendUserParams{
+		ID:             userID,
+		SuspendedUntil: sql.NullTime{Time: until, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = q.RevokeRefreshTokensForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	_, err = q.RevokePersonalAccessTokensForUser(ctx, userID)
+	return err
+}
+
+func isSuspended(user database.User) bool {
+	return user.SuspendedUntil.Valid && time.Now().Before(user.SuspendedUntil.Time)
+}
+
+// respondIfSuspended answers with a 403 naming the end of the suspension
+// and returns true when user is suspended.
+func respondIfSuspended(w http.ResponseWriter, user database.User) bool {
+	if !isSuspended(user) {
+		return false
+	}
+	respondWithError(w, 403, "Account suspended until "+user.SuspendedUntil.Time.UTC().Format(time.RFC3339))
+	return true
+}
+
+// outranks reports whether the moderator holds a higher role than target,
+// so moderators can't restrict each other or the admins.
+func (cfg *apiConfig) outranks(ctx context.Context, moderatorID uuid.UUID, target database.User) bool {
+	moderator, err := cfg.dbQueries.GetUserByID(ctx, moderatorID)
+	if err != nil {
+		return false
+	}
+	return roleRank[moderator.Role] > roleRank[target.Role]
+}
+
+type UserRestrictions struct {
+	UserID         uuid.UUID  `json:"user_id"`
+	SuspendedUntil *time.Time `json:"suspended_until"`
+	Shadowbanned   bool       `json:"shadowbanned"`
+}
+
+func transcribeUserRestrictions(dU database.User) UserRestrictions {
+	restrictions := UserRestrictions{
+		UserID:       dU.ID,
+		Shadowbanned: dU.Shadowbanned,
+	}
+	if isSuspended(dU) {
+		restrictions.SuspendedUntil = &dU.SuspendedUntil.Time
+	}
+	return restrictions
+}
+
+// restrictionAuditActions names the audit log entry for each action that
+// changes a user's restrictions.
+var restrictionAuditActions = map[string]string{
+	actionSuspend:     auditUserSuspended,
+	actionUnsuspend:   auditUserUnsuspended,
+	actionShadowban:   auditUserShadowbanned,
+	actionUnshadowban: auditUserUnshadowbanned,
+}
+
+func restrictionSnapshot(user database.User) map[string]any {
+	return map[string]any{
+		"suspended_until": auditTime(user.SuspendedUntil),
+		"shadowbanned":    user.Shadowbanned,
+	}
+}
+
+// restrictAudited applies change to the user through q and audits it with
+// the user's restrictions before and after. It returns the changed user.
+func restrictAudited(ctx context.Context, q database.Querier, userID uuid.UUID, auditAction string, change func() error) (database.User, error) {
+	before, err := q.GetUserByID(ctx, userID)
+	if err != nil {
+		return database.User{}, err
+	}
+	err = change()
+	if err != nil {
+		return database.User{}, err
+	}
+	after, err := q.GetUserByID(ctx, userID)
+	if err != nil {
+		return database.User{}, err
+	}
+	err = writeAudit(ctx, q, auditEvent{
+		Action:     auditAction,
+		TargetType: auditTargetUser,
+		TargetID:   userID,
+		Before:     restrictionSnapshot(before),
+		After:      restrictionSnapshot(after),
+	})
+	return after, err
+}
+
+// hideChirpAudited hides the chirp through q and audits it.
+func hideChirpAudited(ctx context.Context, q database.Querier, chirpID uuid.UUID) error {
+	before, err := q.GetChirp(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+	err = q.HideChirp(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+	after, err := q.GetChirp(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+	return writeAudit(ctx, q, auditEvent{
+		Action:     auditChirpHidden,
+		TargetType: auditTargetChirp,
+		TargetID:   chirpID,
+		Before:     map[string]any{"hidden_at": auditTime(before.HiddenAt)},
+		After:      map[string]any{"hidden_at": auditTime(after.HiddenAt)},
+	})
+}
+
+// restrictUser runs a moderation action against the user named in the path
+// outside of any report: it checks the caller may restrict them, applies
+// restrict and records and audits the action with note, all in one
+// transaction.
+func (cfg *apiConfig) restrictUser(w http.ResponseWriter, r *http.Request, action, note string, restrict func(ctx context.Context, q database.Querier, userID uuid.UUID) error) {
+	caller, _ := principalFromContext(r.Context())
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid user ID")
+		return
+	}
+	if userID == caller.UserID {
+		respondWithError(w, 400, "You can't restrict yourself")
+		return
+	}
+	target, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	if !cfg.outranks(r.Context(), caller.UserID, target) {
+		respondWithError(w, 403, "You can only restrict users below your role")
+		return
+	}
+
+	var user database.User
+	err = cfg.withTx(r.Context(), func(q database.Querier) error {
+		var err error
+		user, err = restrictAudited(r.Context(), q, userID, restrictionAuditActions[action], func() error {
+			return restrict(r.Context(), q, userID)
+		})
+		if err != nil {
+			return err
+		}
+		_, err = q.CreateModerationAction(r.Context(), database.CreateModerationActionParams{
+			ModeratorID:  uuid.NullUUID{UUID: caller.UserID, Valid: true},
+			Action:       action,
+			TargetUserID: userID,
+			Note:         note,
+		})
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "couldn't apply moderation action", "user_id", userID, "action", action, "error", err)
+		respondWithError(w, 500, "Couldn't apply moderation action")
+		return
+	}
+	slog.InfoContext(r.Context(), "user restricted", "user_id", userID, "action", action, "moderator_id", caller.UserID)
+	respondWithJson(w, 200, transcribeUserRestrictions(user))
+}
+
+func (cfg *apiConfig) handlerSuspendUser(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		SuspendFor string `json:"suspend_for"`
+		Note       string `json:"note"`
+	}
+
+	var params parameters
+	err := json.NewDecoder(r.Body).Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, "Couldn't decode parameters")
+		return
+	}
+	suspendFor, err := time.ParseDuration(params.SuspendFor)
+	if err != nil || suspendFor <= 0 {
+		respondWithError(w, 400, "suspend_for must be a positive duration such as 72h")
+		return
+	}
+	cfg.restrictUser(w, r, actionSuspend, params.Note, func(ctx context.Context, q database.Querier, userID uuid.UUID) error {
+		return suspendUser(ctx, q, userID, time.Now().Add(suspendFor))
+	})
+}
+
+func (cfg *apiConfig) handlerUnsuspendUser(w http.ResponseWriter, r *http.Request) {
+	cfg.restrictUser(w, r, actionUnsuspend, "", func(ctx context.Context, q database.Querier, userID uuid.UUID) error {
+		_, err := q.SuspendUser(ctx, database.SuspendUserParams{ID: userID})
+		return err
+	})
+}
+
+// handlerShadowbanUser hides or shows the user's chirps to everyone else.
+// The user isn't told: their own view of their chirps is unchanged.
+func (cfg *apiConfig) handlerShadowbanUser(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Shadowbanned bool   `json:"shadowbanned"`
+		Note         string `json:"note"`
+	}
+
+	var params parameters
+	err := json.NewDecoder(r.Body).Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, "Couldn't decode parameters")
+		return
+	}
+	action := actionUnshadowban
+	if params.Shadowbanned {
+		action = actionShadowban
+	}
+	cfg.restrictUser(w, r, action, params.Note, func(ctx context.Context, q database.Querier, userID uuid.UUID) error {
+		_, err := q.SetUserShadowbanned(ctx, database.SetUserShadowbannedParams{
+			ID:           userID,
+			Shadowbanned: params.Shadowbanned,
+		})
+		return err
+	})
+}
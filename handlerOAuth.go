@@ -0,0 +1,516 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arglp/chirpy/internal/auth"
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const oauthCodeLifetime = 10 * time.Minute
+
+type OAuthClient struct {
+	ID           uuid.UUID `json:"client_id"`
+	CreatedAt    time.Time `json:"created_at"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"`
+	Confidential bool      `json:"confidential"`
+	Secret       string    `json:"client_secret,omitempty"`
+}
+
+func transcribeOAuthClient(dC database.OauthClient) OAuthClient {
+	return OAuthClient{
+		ID:           dC.ID,
+		CreatedAt:    dC.CreatedAt,
+		Name:         dC.Name,
+		RedirectURIs: dC.RedirectUris,
+		Scopes:       dC.Scopes,
+		Confidential: dC.SecretHash.Valid,
+	}
+}
+
+// respondWithOAuthError writes the error body defined by RFC 6749 section 5.2.
+func respondWithOAuthError(w http.ResponseWriter, code int, oauthError, description string) {
+	type errorResponse struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description,omitempty"`
+	}
+	respondWithJson(w, code, errorResponse{
+		Error:            oauthError,
+		ErrorDescription: description,
+	})
+}
+
+func validRedirectURI(rawURI string) bool {
+	u, err := url.Parse(rawURI)
+	if err != nil || u.Fragment != "" || u.Host == "" {
+		return false
+	}
+	if u.Scheme == "https" {
+		return true
+	}
+	return u.Scheme == "http" && (u.Hostname() == "localhost" || u.Hostname() == "127.0.0.1")
+}
+
+func (cfg *apiConfig) handlerCreateOAuthClient(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name         string   `json:"name"`
+		RedirectURIs []string `json:"redirect_uris"`
+		Scopes       []string `json:"scopes"`
+		Confidential bool     `json:"confidential"`
+	}
+
+	caller, _ := principalFromContext(r.Context())
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, "Something went wrong")
+		return
+	}
+	if params.Name == "" {
+		respondWithError(w, 400, "client name is required")
+		return
+	}
+	if len(params.RedirectURIs) == 0 {
+		respondWithError(w, 400, "at least one redirect uri is required")
+		return
+	}
+	for _, redirectURI := range params.RedirectURIs {
+		if !validRedirectURI(redirectURI) {
+			respondWithError(w, 400, "invalid redirect uri: "+redirectURI)
+			return
+		}
+	}
+	if len(params.Scopes) == 0 {
+		respondWithError(w, 400, "at least one scope is required")
+		return
+	}
+	for _, scope := range params.Scopes {
+		if !auth.ValidScope(scope) {
+			respondWithError(w, 400, "unknown scope: "+scope)
+			return
+		}
+	}
+
+	secret := ""
+	secretHash := sql.NullString{}
+	if params.Confidential {
+		secret, err = auth.MakeRefreshToken()
+		if err != nil {
+			respondWithError(w, 500, "Couldn't make client secret")
+			return
+		}
+		secretHash = sql.NullString{String: auth.HashToken(secret), Valid: true}
+	}
+
+	client, err := cfg.dbQueries.CreateOAuthClient(context.Background(), database.CreateOAuthClientParams{
+		OwnerID:      caller.UserID,
+		Name:         params.Name,
+		SecretHash:   secretHash,
+		RedirectUris: params.RedirectURIs,
+		Scopes:       params.Scopes,
+	})
+	if err != nil {
+		respondWithError(w, 400, "Couldn't create client")
+		return
+	}
+
+	jsonClient := transcribeOAuthClient(client)
+	jsonClient.Secret = secret
+	respondWithJson(w, 201, jsonClient)
+}
+
+type authorizationRequest struct {
+	ResponseType        string `json:"response_type"`
+	ClientID            string `json:"client_id"`
+	RedirectURI         string `json:"redirect_uri"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+}
+
+// validateAuthorizationRequest checks an authorization request against the
+// registered client and returns the client with the scopes being requested.
+// Only the authorization code flow with S256 PKCE is supported.
+func (cfg *apiConfig) validateAuthorizationRequest(req authorizationRequest) (database.OauthClient, []string, error) {
+	if req.ResponseType != "code" {
+		return database.OauthClient{}, nil, errors.New("response_type must be code")
+	}
+	clientID, err := uuid.Parse(req.ClientID)
+	if err != nil {
+		return database.OauthClient{}, nil, errors.New("invalid client_id")
+	}
+	client, err := cfg.dbQueries.GetOAuthClient(context.Background(), clientID)
+	if err != nil {
+		return database.OauthClient{}, nil, errors.New("unknown client")
+	}
+	if !slices.Contains(client.RedirectUris, req.RedirectURI) {
+		return database.OauthClient{}, nil, errors.New("redirect_uri is not registered for this client")
+	}
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != "S256" {
+		return database.OauthClient{}, nil, errors.New("an S256 code_challenge is required")
+	}
+
+	scopes := auth.ParseScope(req.Scope)
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+	for _, scope := range scopes {
+		if !auth.HasScope(client.Scopes, scope) {
+			return database.OauthClient{}, nil, errors.New("scope not allowed for this client: " + scope)
+		}
+	}
+	return client, scopes, nil
+}
+
+// handlerGetAuthorize describes a pending authorization request so the
+// frontend can render the consent screen.
+func (cfg *apiConfig) handlerGetAuthorize(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Client      OAuthClient `json:"client"`
+		Scopes      []string    `json:"scopes"`
+		RedirectURI string      `json:"redirect_uri"`
+	}
+
+	query := r.URL.Query()
+	client, scopes, err := cfg.validateAuthorizationRequest(authorizationRequest{
+		ResponseType:        query.Get("response_type"),
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	})
+	if err != nil {
+		respondWithOAuthError(w, 400, "invalid_request", err.Error())
+		return
+	}
+
+	respondWithJson(w, 200, response{
+		Client:      transcribeOAuthClient(client),
+		Scopes:      scopes,
+		RedirectURI: query.Get("redirect_uri"),
+	})
+}
+
+// handlerPostAuthorize records the signed-in user's consent decision and
+// returns the redirect the user agent should follow back to the client.
+func (cfg *apiConfig) handlerPostAuthorize(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		authorizationRequest
+		Approve bool `json:"approve"`
+	}
+	type response struct {
+		RedirectURI string `json:"redirect_uri"`
+	}
+
+	caller, _ := principalFromContext(r.Context())
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, "Something went wrong")
+		return
+	}
+
+	client, scopes, err := cfg.validateAuthorizationRequest(params.authorizationRequest)
+	if err != nil {
+		respondWithOAuthError(w, 400, "invalid_request", err.Error())
+		return
+	}
+
+	redirect, _ := url.Parse(params.RedirectURI)
+	query := redirect.Query()
+	if params.State != "" {
+		query.Set("state", params.State)
+	}
+
+	if !params.Approve {
+		query.Set("error", "access_denied")
+		redirect.RawQuery = query.Encode()
+		respondWithJson(w, 200, response{RedirectURI: redirect.String()})
+		return
+	}
+
+	code, err := auth.MakeRefreshToken()
+	if err != nil {
+		respondWithError(w, 500, "Couldn't make authorization code")
+		return
+	}
+	err = cfg.dbQueries.CreateOAuthAuthorizationCode(context.Background(), database.CreateOAuthAuthorizationCodeParams{
+		CodeHash:      auth.HashToken(code),
+		ClientID:      client.ID,
+		UserID:        caller.UserID,
+		RedirectUri:   params.RedirectURI,
+		Scopes:        scopes,
+		CodeChallenge: params.CodeChallenge,
+		ExpiresAt:     time.Now().Add(oauthCodeLifetime),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't store authorization code")
+		return
+	}
+
+	query.Set("code", code)
+	redirect.RawQuery = query.Encode()
+	respondWithJson(w, 200, response{RedirectURI: redirect.String()})
+}
+
+// authenticateOAuthClient identifies the client calling the token,
+// revocation or introspection endpoint, via HTTP Basic or form parameters.
+// Public clients only present their client_id.
+func (cfg *apiConfig) authenticateOAuthClient(r *http.Request) (database.OauthClient, error) {
+	clientIDString, secret, ok := r.BasicAuth()
+	if !ok {
+		clientIDString = r.PostForm.Get("client_id")
+		secret = r.PostForm.Get("client_secret")
+	}
+
+	clientID, err := uuid.Parse(clientIDString)
+	if err != nil {
+		return database.OauthClient{}, errors.New("invalid client_id")
+	}
+	client, err := cfg.dbQueries.GetOAuthClient(context.Background(), clientID)
+	if err != nil {
+		return database.OauthClient{}, errors.New("unknown client")
+	}
+	if client.SecretHash.Valid {
+		given := auth.HashToken(secret)
+		if subtle.ConstantTimeCompare([]byte(given), []byte(client.SecretHash.String)) != 1 {
+			return database.OauthClient{}, errors.New("invalid client credentials")
+		}
+	}
+	return client, nil
+}
+
+func (cfg *apiConfig) handlerOAuthToken(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		respondWithOAuthError(w, 400, "invalid_request", "couldn't parse form")
+		return
+	}
+
+	client, err := cfg.authenticateOAuthClient(r)
+	if err != nil {
+		respondWithOAuthError(w, 401, "invalid_client", err.Error())
+		return
+	}
+
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		cfg.exchangeAuthorizationCode(w, r, client)
+	case "refresh_token":
+		cfg.exchangeOAuthRefreshToken(w, r, client)
+	default:
+		respondWithOAuthError(w, 400, "unsupported_grant_type", "")
+	}
+}
+
+func (cfg *apiConfig) exchangeAuthorizationCode(w http.ResponseWriter, r *http.Request, client database.OauthClient) {
+	code, err := cfg.dbQueries.ConsumeOAuthAuthorizationCode(context.Background(), auth.HashToken(r.PostForm.Get("code")))
+	if err != nil {
+		respondWithOAuthError(w, 400, "invalid_grant", "unknown or already used code")
+		return
+	}
+	if code.ClientID != client.ID {
+		respondWithOAuthError(w, 400, "invalid_grant", "code was issued to another client")
+		return
+	}
+	if time.Now().After(code.ExpiresAt) {
+		respondWithOAuthError(w, 400, "invalid_grant", "code expired")
+		return
+	}
+	if code.RedirectUri != r.PostForm.Get("redirect_uri") {
+		respondWithOAuthError(w, 400, "invalid_grant", "redirect_uri mismatch")
+		return
+	}
+	if !auth.VerifyPKCE(r.PostForm.Get("code_verifier"), code.CodeChallenge) {
+		respondWithOAuthError(w, 400, "invalid_grant", "code_verifier mismatch")
+		return
+	}
+
+	cfg.issueOAuthTokens(w, code.UserID, client.ID, code.Scopes)
+}
+
+// exchangeOAuthRefreshToken rotates the refresh token: the presented one is
+// revoked and a new one is issued alongside the access token.
+func (cfg *apiConfig) exchangeOAuthRefreshToken(w http.ResponseWriter, r *http.Request, client database.OauthClient) {
+	refreshToken, err := cfg.dbQueries.GetRefreshToken(context.Background(), r.PostForm.Get("refresh_token"))
+	if err != nil {
+		respondWithOAuthError(w, 400, "invalid_grant", "unknown refresh token")
+		return
+	}
+	if !refreshToken.ClientID.Valid || refreshToken.ClientID.UUID != client.ID {
+		respondWithOAuthError(w, 400, "invalid_grant", "refresh token was issued to another client")
+		return
+	}
+	if refreshToken.RevokedAt.Valid || time.Now().After(refreshToken.ExpiresAt) {
+		respondWithOAuthError(w, 400, "invalid_grant", "refresh token expired or revoked")
+		return
+	}
+
+	scopes := refreshToken.Scopes
+	if requested := auth.ParseScope(r.PostForm.Get("scope")); len(requested) > 0 {
+		for _, scope := range requested {
+			if !auth.HasScope(refreshToken.Scopes, scope) {
+				respondWithOAuthError(w, 400, "invalid_scope", "scope exceeds the original grant: "+scope)
+				return
+			}
+		}
+		scopes = requested
+	}
+
+	err = cfg.dbQueries.RevokeRefreshToken(context.Background(), refreshToken.Token)
+	if err != nil {
+		respondWithOAuthError(w, 500, "server_error", "")
+		return
+	}
+	cfg.issueOAuthTokens(w, refreshToken.UserID, client.ID, scopes)
+}
+
+func (cfg *apiConfig) issueOAuthTokens(w http.ResponseWriter, userID, clientID uuid.UUID, scopes []string) {
+	type response struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int    `json:"expires_in"`
+		RefreshToken string `json:"refresh_token"`
+		Scope        string `json:"scope"`
+	}
+
+	user, err := cfg.dbQueries.GetUserByID(context.Background(), userID)
+	if err != nil {
+		respondWithOAuthError(w, 400, "invalid_grant", "user no longer exists")
+		return
+	}
+	if isSuspended(user) {
+		respondWithOAuthError(w, 400, "invalid_grant", "account suspended")
+		return
+	}
+
+	accessToken, err := auth.MakeScopedJWT(userID, cfg.secret, cfg.accessTokenTTL, clientID, scopes)
+	if err != nil {
+		respondWithOAuthError(w, 500, "server_error", "")
+		return
+	}
+	refreshTokenString, err := auth.MakeRefreshToken()
+	if err != nil {
+		respondWithOAuthError(w, 500, "server_error", "")
+		return
+	}
+	_, err = cfg.dbQueries.CreateOAuthRefreshToken(context.Background(), database.CreateOAuthRefreshTokenParams{
+		Token:     refreshTokenString,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(cfg.refreshTokenTTL),
+		ClientID:  uuid.NullUUID{UUID: clientID, Valid: true},
+		Scopes:    scopes,
+	})
+	if err != nil {
+		respondWithOAuthError(w, 500, "server_error", "")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	respondWithJson(w, 200, response{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(cfg.accessTokenTTL.Seconds()),
+		RefreshToken: refreshTokenString,
+		Scope:        strings.Join(scopes, " "),
+	})
+}
+
+// handlerOAuthRevoke implements RFC 7009. Access tokens are stateless JWTs
+// and simply expire, so only refresh tokens are revoked. Unknown tokens are
+// not an error.
+func (cfg *apiConfig) handlerOAuthRevoke(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		respondWithOAuthError(w, 400, "invalid_request", "couldn't parse form")
+		return
+	}
+	client, err := cfg.authenticateOAuthClient(r)
+	if err != nil {
+		respondWithOAuthError(w, 401, "invalid_client", err.Error())
+		return
+	}
+
+	refreshToken, err := cfg.dbQueries.GetRefreshToken(context.Background(), r.PostForm.Get("token"))
+	if err == nil && refreshToken.ClientID.Valid && refreshToken.ClientID.UUID == client.ID {
+		err = cfg.dbQueries.RevokeRefreshToken(context.Background(), refreshToken.Token)
+		if err != nil {
+			respondWithOAuthError(w, 500, "server_error", "")
+			return
+		}
+	}
+	w.WriteHeader(200)
+}
+
+// handlerOAuthIntrospect implements RFC 7662 for tokens issued to the
+// calling client.
+func (cfg *apiConfig) handlerOAuthIntrospect(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Active    bool   `json:"active"`
+		Scope     string `json:"scope,omitempty"`
+		ClientID  string `json:"client_id,omitempty"`
+		Subject   string `json:"sub,omitempty"`
+		ExpiresAt int64  `json:"exp,omitempty"`
+		TokenType string `json:"token_type,omitempty"`
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		respondWithOAuthError(w, 400, "invalid_request", "couldn't parse form")
+		return
+	}
+	client, err := cfg.authenticateOAuthClient(r)
+	if err != nil {
+		respondWithOAuthError(w, 401, "invalid_client", err.Error())
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	accessToken, err := auth.ParseAccessToken(token, cfg.secret)
+	if err == nil && accessToken.Scoped && accessToken.ClientID == client.ID {
+		respondWithJson(w, 200, response{
+			Active:    true,
+			Scope:     strings.Join(accessToken.Scopes, " "),
+			ClientID:  client.ID.String(),
+			Subject:   accessToken.UserID.String(),
+			ExpiresAt: accessToken.ExpiresAt.Unix(),
+			TokenType: "access_token",
+		})
+		return
+	}
+
+	refreshToken, err := cfg.dbQueries.GetRefreshToken(context.Background(), token)
+	if err == nil && refreshToken.ClientID.Valid && refreshToken.ClientID.UUID == client.ID &&
+		!refreshToken.RevokedAt.Valid && time.Now().Before(refreshToken.ExpiresAt) {
+		respondWithJson(w, 200, response{
+			Active:    true,
+			Scope:     strings.Join(refreshToken.Scopes, " "),
+			ClientID:  client.ID.String(),
+			Subject:   refreshToken.UserID.String(),
+			ExpiresAt: refreshToken.ExpiresAt.Unix(),
+			TokenType: "refresh_token",
+		})
+		return
+	}
+
+	respondWithJson(w, 200, response{Active: false})
+}
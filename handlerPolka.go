@@ -2,50 +2,176 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
 	"net/http"
-	"fmt"
+	"time"
+
 	"github.com/arglp/chirpy/internal/auth"
+	"github.com/arglp/chirpy/internal/database"
 	"github.com/google/uuid"
 )
 
+const polkaSignatureTolerance = 5 * time.Minute
+
+// Polka events are a few hundred bytes; anything much larger isn't one.
+const polkaMaxBodyBytes = 64 << 10
+
+const (
+	webhookOutcomeProcessed = "processed"
+	webhookOutcomeDuplicate = "duplicate"
+	webhookOutcomeIgnored   = "ignored"
+	webhookOutcomeRejected  = "rejected"
+	webhookOutcomeFailed    = "failed"
+)
+
+// webhookResult is how an inbound webhook was handled. It is both sent back
+// to the caller and stored in the inbound webhook log.
+type webhookResult struct {
+	StatusCode int
+	Outcome    string
+	Err        error
+}
+
 func (cfg *apiConfig) handlerPolkaWebhook(w http.ResponseWriter, r *http.Request) {
-	fmt.Println("Headers:", r.Header)
-	
+	slog.DebugContext(r.Context(), "polka webhook received", "headers", redactHeaders(r.Header))
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, polkaMaxBodyBytes))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "body too large")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "couldn't read body")
+		return
+	}
+
+	// Every delivery is logged before it is processed, so failures can be
+	// inspected and replayed later. If that isn't possible Polka should
+	// retry rather than have the event dropped unrecorded.
+	headers, err := json.Marshal(redactHeaders(r.Header))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't record event")
+		return
+	}
+	logged, err := cfg.dbQueries.CreateInboundWebhook(context.Background(), database.CreateInboundWebhookParams{
+		Source: "polka",
+		Headers: headers,
+		Body: string(body),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "couldn't record event")
+		return
+	}
+
+	result := cfg.verifyPolkaRequest(r.Header, body)
+	if result.Err == nil {
+		result = cfg.processPolkaEvent(r.Context(), body)
+	}
+	cfg.finishInboundWebhook(r.Context(), logged.ID, result)
+	cfg.metrics.webhookEvents.WithLabelValues("polka", result.Outcome).Inc()
+
+	if result.Err != nil {
+		respondWithError(w, result.StatusCode, result.Err.Error())
+		return
+	}
+	w.WriteHeader(result.StatusCode)
+}
+
+func (cfg *apiConfig) verifyPolkaRequest(header http.Header, body []byte) webhookResult {
+	apiKey, err := auth.GetAPIKey(header)
+	if err != nil {
+		return webhookResult{http.StatusUnauthorized, webhookOutcomeRejected, errors.New("Couldn't find api key")}
+	}
+	if subtle.ConstantTimeCompare([]byte(apiKey), []byte(cfg.polkaKey)) != 1 {
+		return webhookResult{http.StatusUnauthorized, webhookOutcomeRejected, errors.New("apiKey not correct")}
+	}
+
+	// The secret is only optional on the dev platform; config requires it
+	// everywhere else.
+	if cfg.polkaWebhookSecret != "" {
+		err = auth.VerifyWebhookSignature(header.Get("X-Polka-Signature"), body, cfg.polkaWebhookSecret, polkaSignatureTolerance, time.Now())
+		if err != nil {
+			return webhookResult{http.StatusUnauthorized, webhookOutcomeRejected, errors.New("invalid signature")}
+		}
+	}
+	return webhookResult{}
+}
+
+// processPolkaEvent applies an authenticated Polka event. It is shared by
+// the webhook endpoint and the admin replay endpoint.
+func (cfg *apiConfig) processPolkaEvent(ctx context.Context, body []byte) webhookResult {
 	type parameters struct {
+		ID    string `json:"id"`
 		Event string `json:"event"`
 		Data struct {
-			UserID uuid.UUID `json:"user_id"`
+			UserID    uuid.UUID `json:"user_id"`
+			PeriodEnd time.Time `json:"period_end"`
 		}
 	}
-	apiKey, err := auth.GetAPIKey(r.Header)
+
+	params := parameters{}
+	err := json.Unmarshal(body, &params)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't find api key")
-		return
+		return webhookResult{http.StatusBadRequest, webhookOutcomeRejected, errors.New("invalid request body")}
 	}
-	if apiKey != cfg.polkaKey {
-		respondWithError(w, http.StatusUnauthorized, "apiKey not correct")
-		return
+	switch params.Event {
+	case "user.upgraded", "user.renewed", "user.cancelled", "user.downgraded":
+	default:
+		return webhookResult{http.StatusNoContent, webhookOutcomeIgnored, nil}
+	}
+	if params.ID == "" {
+		return webhookResult{http.StatusBadRequest, webhookOutcomeRejected, errors.New("event id is required")}
 	}
 
-	decoder := json.NewDecoder(r.Body)
-	params := parameters{}
-	err = decoder.Decode(&params)
+	// Polka retries deliveries, so each event ID is only processed once.
+	// The claim is released again if processing fails, so a retry can
+	// succeed later.
+	claimed, err := cfg.dbQueries.ClaimWebhookEvent(ctx, database.ClaimWebhookEventParams{
+		EventID: params.ID,
+		Source: "polka",
+		Event: params.Event,
+	})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "something went wrong")
-		return
+		return webhookResult{http.StatusInternalServerError, webhookOutcomeFailed, errors.New("couldn't record event")}
 	}
-	if params.Event != "user.upgraded" {
-		w.WriteHeader(http.StatusNoContent)
-		return
+	if claimed == 0 {
+		return webhookResult{http.StatusNoContent, webhookOutcomeDuplicate, nil}
 	}
 
-	userID := params.Data.UserID
+	err = cfg.applySubscriptionEvent(ctx, params.Data.UserID, params.Event, params.Data.PeriodEnd)
+	if err != nil {
+		releaseErr := cfg.dbQueries.ReleaseWebhookEvent(ctx, params.ID)
+		if releaseErr != nil {
+			slog.ErrorContext(ctx, "couldn't release webhook event", "event_id", params.ID, "error", releaseErr)
+		}
+		if errors.Is(err, errUserNotFound) || errors.Is(err, errSubscriptionNotFound) {
+			return webhookResult{http.StatusNotFound, webhookOutcomeFailed, err}
+		}
+		slog.ErrorContext(ctx, "couldn't process polka event", "event_id", params.ID, "error", err)
+		return webhookResult{http.StatusInternalServerError, webhookOutcomeFailed, errors.New("couldn't process event")}
+	}
+	return webhookResult{http.StatusNoContent, webhookOutcomeProcessed, nil}
+}
 
-	_, err = cfg.dbQueries.SetUserChirpyRed(context.Background(), userID)
+func (cfg *apiConfig) finishInboundWebhook(ctx context.Context, id uuid.UUID, result webhookResult) database.InboundWebhook {
+	errorMessage := sql.NullString{}
+	if result.Err != nil {
+		errorMessage = sql.NullString{String: result.Err.Error(), Valid: true}
+	}
+	logged, err := cfg.dbQueries.FinishInboundWebhook(ctx, database.FinishInboundWebhookParams{
+		ID: id,
+		StatusCode: sql.NullInt32{Int32: int32(result.StatusCode), Valid: true},
+		Outcome: sql.NullString{String: result.Outcome, Valid: true},
+		Error: errorMessage,
+	})
 	if err != nil {
-		respondWithError(w, 404, "user not found")
-		return
+		slog.ErrorContext(ctx, "couldn't record inbound webhook outcome", "inbound_webhook_id", id, "error", err)
 	}
-	w.WriteHeader(204)
-}
\ No newline at end of file
+	return logged
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// RelatedUser is an entry in the caller's block or mute list.
+type RelatedUser struct {
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// relationshipTarget reads the user named in the path and checks that it
+// exists and isn't the caller, writing the error response itself if not.
+func (cfg *apiConfig) relationshipTarget(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	caller, _ := principalFromContext(r.Context())
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid user ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	if userID == caller.UserID {
+		respondWithError(w, 400, "You can't block or mute yourself")
+		return uuid.Nil, uuid.Nil, false
+	}
+	_, err = cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return caller.UserID, userID, true
+}
+
+// handlerBlockUser hides the two users' chirps from each other. Blocking
+// someone already blocked is not an error.
+func (cfg *apiConfig) handlerBlockUser(w http.ResponseWriter, r *http.Request) {
+	callerID, userID, ok := cfg.relationshipTarget(w, r)
+	if !ok {
+		return
+	}
+	err := cfg.dbQueries.BlockUser(r.Context(), database.BlockUserParams{
+		BlockerID: callerID,
+		BlockedID: userID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't block user")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) handlerUnblockUser(w http.ResponseWriter, r *http.Request) {
+	callerID, userID, ok := cfg.relationshipTarget(w, r)
+	if !ok {
+		return
+	}
+	err := cfg.dbQueries.UnblockUser(r.Context(), database.UnblockUserParams{
+		BlockerID: callerID,
+		BlockedID: userID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't unblock user")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) handlerGetBlocks(w http.ResponseWriter, r *http.Request) {
+	caller, _ := principalFromContext(r.Context())
+
+	blocks, err := cfg.dbQueries.GetBlocksForUser(r.Context(), caller.UserID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get blocked users")
+		return
+	}
+	users := []RelatedUser{}
+	for _, block := range blocks {
+		users = append(users, RelatedUser{UserID: block.BlockedID, CreatedAt: block.CreatedAt})
+	}
+	respondWithJson(w, 200, users)
+}
+
+// handlerMuteUser hides the user's chirps from the caller only. The muted
+// user can still see the caller's chirps and isn't told.
+func (cfg *apiConfig) handlerMuteUser(w http.ResponseWriter, r *http.Request) {
+	callerID, userID, ok := cfg.relationshipTarget(w, r)
+	if !ok {
+		return
+	}
+	err := cfg.dbQueries.MuteUser(r.Context(), database.MuteUserParams{
+		MuterID: callerID,
+		MutedID: userID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't mute user")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) handlerUnmuteUser(w http.ResponseWriter, r *http.Request) {
+	callerID, userID, ok := cfg.relationshipTarget(w, r)
+	if !ok {
+		return
+	}
+	err := cfg.dbQueries.UnmuteUser(r.Context(), database.UnmuteUserParams{
+		MuterID: callerID,
+		MutedID: userID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't unmute user")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) handlerGetMutes(w http.ResponseWriter, r *http.Request) {
+	caller, _ := principalFromContext(r.Context())
+
+	mutes, err := cfg.dbQueries.GetMutesForUser(r.Context(), caller.UserID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get muted users")
+		return
+	}
+	users := []RelatedUser{}
+	for _, mute := range mutes {
+		users = append(users, RelatedUser{UserID: mute.MutedID, CreatedAt: mute.CreatedAt})
+	}
+	respondWithJson(w, 200, users)
+}
@@ -16,6 +16,11 @@ func (cfg *apiConfig) handlerReset(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	cfg.recordAudit(req.Context(), auditEvent{
+		Action:     auditReset,
+		TargetType: auditTargetSystem,
+	})
+
 	cfg.fileserverHits.Store(0)
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
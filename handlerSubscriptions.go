@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type Subscription struct {
+	ID               uuid.UUID           `json:"id"`
+	Plan             string              `json:"plan"`
+	Status           string              `json:"status"`
+	CurrentPeriodEnd time.Time           `json:"current_period_end"`
+	History          []SubscriptionEvent `json:"history"`
+}
+
+type SubscriptionEvent struct {
+	CreatedAt        time.Time `json:"created_at"`
+	Event            string    `json:"event"`
+	Status           string    `json:"status"`
+	CurrentPeriodEnd time.Time `json:"current_period_end"`
+}
+
+func transcribeSubscription(dS database.Subscription, dEvents []database.SubscriptionEvent) Subscription {
+	subscription := Subscription{
+		ID:               dS.ID,
+		Plan:             dS.Plan,
+		Status:           dS.Status,
+		CurrentPeriodEnd: dS.CurrentPeriodEnd,
+		History:          []SubscriptionEvent{},
+	}
+	for _, dE := range dEvents {
+		subscription.History = append(subscription.History, SubscriptionEvent{
+			CreatedAt:        dE.CreatedAt,
+			Event:            dE.Event,
+			Status:           dE.Status,
+			CurrentPeriodEnd: dE.CurrentPeriodEnd,
+		})
+	}
+	return subscription
+}
+
+func (cfg *apiConfig) handlerGetSubscription(w http.ResponseWriter, r *http.Request) {
+	caller, _ := principalFromContext(r.Context())
+
+	subscription, err := cfg.dbQueries.GetSubscriptionByUser(context.Background(), caller.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "no subscription")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "couldn't get subscription")
+		return
+	}
+	events, err := cfg.dbQueries.GetSubscriptionEvents(context.Background(), subscription.ID)
+	if err != nil {
+		respondWithError(w, 500, "couldn't get subscription history")
+		return
+	}
+	respondWithJson(w, 200, transcribeSubscription(subscription, events))
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/arglp/chirpy/internal/auth"
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type PersonalAccessToken struct {
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	Token      string     `json:"token,omitempty"`
+}
+
+func transcribePersonalAccessToken(dT database.PersonalAccessToken) PersonalAccessToken {
+	token := PersonalAccessToken{
+		ID:        dT.ID,
+		CreatedAt: dT.CreatedAt,
+		Name:      dT.Name,
+		Scopes:    dT.Scopes,
+	}
+	if dT.ExpiresAt.Valid {
+		token.ExpiresAt = &dT.ExpiresAt.Time
+	}
+	if dT.LastUsedAt.Valid {
+		token.LastUsedAt = &dT.LastUsedAt.Time
+	}
+	return token
+}
+
+func (cfg *apiConfig) handlerCreateToken(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	caller, _ := principalFromContext(r.Context())
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, "Something went wrong")
+		return
+	}
+	if params.Name == "" {
+		respondWithError(w, 400, "token name is required")
+		return
+	}
+	if len(params.Scopes) == 0 {
+		respondWithError(w, 400, "at least one scope is required")
+		return
+	}
+	for _, scope := range params.Scopes {
+		if !auth.ValidScope(scope) {
+			respondWithError(w, 400, "unknown scope: "+scope)
+			return
+		}
+	}
+
+	expiresAt := sql.NullTime{}
+	if params.ExpiresAt != nil {
+		if params.ExpiresAt.Before(time.Now()) {
+			respondWithError(w, 400, "expires_at is in the past")
+			return
+		}
+		expiresAt = sql.NullTime{Time: *params.ExpiresAt, Valid: true}
+	}
+
+	tokenString, err := auth.MakePersonalAccessToken()
+	if err != nil {
+		respondWithError(w, 500, "Couldn't make token")
+		return
+	}
+
+	token, err := cfg.dbQueries.CreatePersonalAccessToken(context.Background(), database.CreatePersonalAccessTokenParams{
+		UserID:    caller.UserID,
+		Name:      params.Name,
+		TokenHash: auth.HashToken(tokenString),
+		Scopes:    params.Scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		respondWithError(w, 400, "Couldn't create token")
+		return
+	}
+
+	// The plaintext token is only ever returned here.
+	jsonToken := transcribePersonalAccessToken(token)
+	jsonToken.Token = tokenString
+	respondWithJson(w, 201, jsonToken)
+}
+
+func (cfg *apiConfig) handlerGetTokens(w http.ResponseWriter, r *http.Request) {
+	caller, _ := principalFromContext(r.Context())
+
+	results, err := cfg.dbQueries.GetPersonalAccessTokensForUser(context.Background(), caller.UserID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get tokens")
+		return
+	}
+	tokens := []PersonalAccessToken{}
+	for _, result := range results {
+		tokens = append(tokens, transcribePersonalAccessToken(result))
+	}
+	respondWithJson(w, 200, tokens)
+}
+
+func (cfg *apiConfig) handlerRevokeToken(w http.ResponseWriter, r *http.Request) {
+	caller, _ := principalFromContext(r.Context())
+
+	tokenID, err := uuid.Parse(r.PathValue("tokenID"))
+	if err != nil {
+		respondWithError(w, 400, "invalid token id")
+		return
+	}
+
+	revoked, err := cfg.dbQueries.RevokePersonalAccessToken(context.Background(), database.RevokePersonalAccessTokenParams{
+		ID:     tokenID,
+		UserID: caller.UserID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't revoke token")
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, 404, "couldn't find token")
+		return
+	}
+	w.WriteHeader(204)
+}
@@ -3,32 +3,49 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/arglp/chirpy/internal/auth"
 	"github.com/arglp/chirpy/internal/database"
+	"github.com/arglp/chirpy/internal/entitlements"
 	"github.com/google/uuid"
 )
 
+const (
+	roleUser      = "user"
+	roleModerator = "moderator"
+	roleAdmin     = "admin"
+)
+
 type User struct {
 	ID        uuid.UUID `json:"id"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	Email     string    `json:"email"`
 	IsChirpyRed bool	`json:"is_chirpy_red"`
+	Role	string	`json:"role"`
+	Entitlements entitlements.Entitlements `json:"entitlements"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
 	Token 	  string	`json:"token"`
 	RefreshToken	string`json:"refresh_token"`
 }
 
 func transcribeUser(dU database.User) User {
-	return User{
+	user := User{
 		ID:	dU.ID,
 		CreatedAt: dU.CreatedAt,
 		UpdatedAt: dU.UpdatedAt,
 		Email: dU.Email,
 		IsChirpyRed: dU.IsChirpyRed,
+		Role: dU.Role,
+		Entitlements: entitlements.For(entitlements.PlanFor(dU.IsChirpyRed)),
+	}
+	if dU.DeletionScheduledAt.Valid {
+		user.DeletionScheduledAt = &dU.DeletionScheduledAt.Time
 	}
+	return user
 }
 
 func (cfg *apiConfig) handlerUsers(w http.ResponseWriter, r *http.Request) {
@@ -45,7 +62,7 @@ func (cfg *apiConfig) handlerUsers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(params.Password)
+	hashedPassword, err := auth.HashPasswordWithParams(params.Password, cfg.passwordParams)
 	if err != nil {
 		respondWithError(w, 400, "Something went wrong")
 		return
@@ -58,6 +75,7 @@ func (cfg *apiConfig) handlerUsers(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 		respondWithError(w, 400, "Couldn*t create user")
+		return
 	}
 	respondWithJson(w, 201, transcribeUser(user))
 }
@@ -77,47 +95,63 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	expiresIn := time.Hour
-
 	user, err := cfg.dbQueries.GetUser(context.Background(), params.Email)
 	if err != nil {
+		cfg.metrics.failedLogins.Inc()
 		respondWithError(w, 401, "Incorrect email or password")
 		return
 	}
 	ok, err := auth.CheckPasswordHash(params.Password, user.HashedPassword)
 	if err != nil {
+		cfg.metrics.failedLogins.Inc()
 		respondWithError(w, 401, "Couldn't check password")
 		return
 	}
 	if !ok {
+		cfg.metrics.failedLogins.Inc()
 		respondWithError(w, 401, "Incorrect email or password")
 		return
 	}
+	if respondIfSuspended(w, user) {
+		return
+	}
+	cfg.upgradePasswordHash(r.Context(), user, params.Password)
+
+	cfg.respondWithSession(w, user, "password")
+}
 
-	tokenString, err := auth.MakeJWT(user.ID, cfg.secret, time.Duration(expiresIn))
+// respondWithSession issues a new access/refresh token pair for user and
+// responds with the user JSON carrying both. method names how the user
+// logged in, for the login metrics.
+func (cfg *apiConfig) respondWithSession(w http.ResponseWriter, user database.User, method string) {
+	tokenString, err := auth.MakeJWT(user.ID, cfg.secret, cfg.accessTokenTTL)
 	if err != nil {
 		respondWithError(w, 401, "Couldn't make JWT")
+		return
 	}
 
 	refreshTokenString, err := auth.MakeRefreshToken()
 	if err != nil {
 		respondWithError(w, 401, "Couldn't make refresh token")
+		return
 	}
 
 	refreshToken, err := cfg.dbQueries.CreateRefreshToken(context.Background(), database.CreateRefreshTokenParams{
 		Token: refreshTokenString,
 		UserID: user.ID,
-		ExpiresAt: time.Now().AddDate(0, 0, 60),
+		ExpiresAt: time.Now().Add(cfg.refreshTokenTTL),
 	})
 
 	if err != nil {
 		respondWithError(w, 400, "something went wrong")
+		return
 	}
 	
 	jsonUser := transcribeUser(user)
 	jsonUser.Token = tokenString
 	jsonUser.RefreshToken = refreshToken.Token
 
+	cfg.metrics.logins.WithLabelValues(method).Inc()
 	respondWithJson(w, 200, jsonUser)
 }
 
@@ -128,26 +162,38 @@ func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 
 	token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		respondWithError(w, 400, "something went wrong")
+		respondUnauthorized(w, "")
 		return
 	}
 
 	refreshToken, err := cfg.dbQueries.GetUserFromRefreshToken(context.Background(), token)
 	if err != nil {
-		respondWithError(w, 401, "couldn't find refresh token")
+		respondUnauthorized(w, "unknown refresh token")
 		return
 	}
 
 	if time.Now().After(refreshToken.ExpiresAt) {
-		respondWithError(w, 401, "refresh token expired")
+		respondUnauthorized(w, "refresh token expired")
 		return
 	}
 	if refreshToken.RevokedAt.Valid {
-		respondWithError(w, 401, "refresh token revoked")
+		respondUnauthorized(w, "refresh token revoked")
+		return
+	}
+	if refreshToken.ClientID.Valid {
+		respondUnauthorized(w, "refresh token belongs to an oauth client")
+		return
+	}
+	user, err := cfg.dbQueries.GetUserByID(context.Background(), refreshToken.UserID)
+	if err != nil {
+		respondUnauthorized(w, "user no longer exists")
+		return
+	}
+	if respondIfSuspended(w, user) {
 		return
 	}
 
-	accessToken, err := auth.MakeJWT(refreshToken.UserID, cfg.secret, time.Hour)
+	accessToken, err := auth.MakeJWT(refreshToken.UserID, cfg.secret, cfg.accessTokenTTL)
 	if err != nil {
 		respondWithError(w, 401, "something went wrong")
 		return
@@ -159,23 +205,28 @@ func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 func (cfg *apiConfig) handlerRevoke(w http.ResponseWriter, r *http.Request) {
 	token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		respondWithError(w, 401, "something went wrong")
+		respondUnauthorized(w, "")
 		return
 	}
 	err = cfg.dbQueries.RevokeRefreshToken(context.Background(), token)
 	if err != nil {
-		respondWithError(w, 401, "couldn't find refresh token")
+		respondUnauthorized(w, "unknown refresh token")
 		return
 	}
 	w.WriteHeader(204)
 }
 
+// handlerUpdateUser replaces the caller's email and password. It is routed
+// through requireLogin: a leaked personal access token or OAuth token must
+// not be enough to take over the account.
 func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
 		Email string `json:"email"`
 		Password string `json:"password"`
 	}
 
+	caller, _ := principalFromContext(r.Context())
+
 	decoder := json.NewDecoder(r.Body)
 	params := parameters{}
 	err := decoder.Decode(&params)
@@ -184,36 +235,62 @@ func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, 401, "couldn't find access code")
-		return
-	}
-
-	hashedPassword, err := auth.HashPassword(params.Password)
+	hashedPassword, err := auth.HashPasswordWithParams(params.Password, cfg.passwordParams)
 	if err != nil {
 		respondWithError(w, 401, "couldn't hash password")
 		return
 	}
 
-	id, err := auth.ValidateJWT(token, cfg.secret)
+	previous, err := cfg.dbQueries.GetUserByID(context.Background(), caller.UserID)
 	if err != nil {
-		respondWithError(w, 401, "couldn't vaildate access code")
+		respondWithError(w, 401, "couldn't get user")
 		return
 	}
 
 	user, err := cfg.dbQueries.SetUserEmailPassword(context.Background(), database.SetUserEmailPasswordParams{
 		Email: params.Email,
 		HashedPassword: hashedPassword,
-		ID: id,
+		ID: caller.UserID,
 	})
 
 	if err != nil {
 		respondWithError(w, 401, "couldn't get user")
 		return
 	}
+	// Password hashes stay out of the log; only the fact of a change is
+	// recorded.
+	cfg.recordAudit(r.Context(), auditEvent{
+		Action:     auditUserUpdated,
+		TargetType: auditTargetUser,
+		TargetID:   user.ID,
+		Before:     map[string]any{"email": previous.Email},
+		After:      map[string]any{"email": user.Email, "password_changed": true},
+	})
 
 	jsonUser := transcribeUser(user)
 
 	respondWithJson(w, 200, jsonUser)
-}
\ No newline at end of file
+}
+
+// upgradePasswordHash re-hashes the password of a user whose stored hash was
+// created with weaker argon2id parameters than the configured ones. It only
+// runs after a successful login, when the plaintext password is known.
+func (cfg *apiConfig) upgradePasswordHash(ctx context.Context, user database.User, password string) {
+	rehash, err := auth.PasswordNeedsRehash(user.HashedPassword, cfg.passwordParams)
+	if err != nil || !rehash {
+		return
+	}
+
+	hashedPassword, err := auth.HashPasswordWithParams(password, cfg.passwordParams)
+	if err != nil {
+		slog.ErrorContext(ctx, "couldn't re-hash password", "user_id", user.ID, "error", err)
+		return
+	}
+	err = cfg.dbQueries.SetUserPassword(ctx, database.SetUserPasswordParams{
+		HashedPassword: hashedPassword,
+		ID: user.ID,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "couldn't store re-hashed password", "user_id", user.ID, "error", err)
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"time"
+
+	"github.com/arglp/chirpy/internal/auth"
+	"github.com/arglp/chirpy/internal/config"
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/arglp/chirpy/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+type WebhookSubscription struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID       `json:"id"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	LastStatusCode *int32          `json:"last_status_code"`
+	LastError      *string         `json:"last_error"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+}
+
+func transcribeWebhookSubscription(dS database.WebhookSubscription) WebhookSubscription {
+	return WebhookSubscription{
+		ID:        dS.ID,
+		CreatedAt: dS.CreatedAt,
+		URL:       dS.Url,
+		Events:    dS.Events,
+	}
+}
+
+func transcribeWebhookDelivery(dD database.WebhookDelivery) WebhookDelivery {
+	delivery := WebhookDelivery{
+		ID:        dD.ID,
+		CreatedAt: dD.CreatedAt,
+		Event:     dD.Event,
+		Payload:   dD.Payload,
+		Status:    dD.Status,
+		Attempts:  dD.Attempts,
+	}
+	if dD.Status == webhookDeliveryPending {
+		delivery.NextAttemptAt = &dD.NextAttemptAt
+	}
+	if dD.LastStatusCode.Valid {
+		delivery.LastStatusCode = &dD.LastStatusCode.Int32
+	}
+	if dD.LastError.Valid {
+		delivery.LastError = &dD.LastError.String
+	}
+	if dD.DeliveredAt.Valid {
+		delivery.DeliveredAt = &dD.DeliveredAt.Time
+	}
+	return delivery
+}
+
+// Callbacks must use HTTPS and resolve to public addresses, except on the
+// dev platform where local receivers are allowed.
+func (cfg *apiConfig) validWebhookURL(ctx context.Context, rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	if cfg.platform == config.PlatformDev {
+		return u.Scheme == "https" || u.Scheme == "http"
+	}
+	if u.Scheme != "https" {
+		return false
+	}
+	if addr, err := netip.ParseAddr(u.Hostname()); err == nil {
+		return webhooks.PublicAddr(addr)
+	}
+	return webhooks.LookupPublic(ctx, u.Hostname()) == nil
+}
+
+func (cfg *apiConfig) handlerCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+	}
+
+	caller, _ := principalFromContext(r.Context())
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, 400, "Something went wrong")
+		return
+	}
+	if !cfg.validWebhookURL(r.Context(), params.URL) {
+		respondWithError(w, 400, "url must be a public https url")
+		return
+	}
+	if len(params.Events) == 0 {
+		respondWithError(w, 400, "at least one event is required")
+		return
+	}
+	for _, event := range params.Events {
+		if !webhooks.ValidEvent(event) {
+			respondWithError(w, 400, "unknown event: "+event)
+			return
+		}
+	}
+
+	// The secret is kept in plaintext because every delivery is signed
+	// with it.
+	secret, err := auth.MakeRefreshToken()
+	if err != nil {
+		respondWithError(w, 500, "Couldn't make webhook secret")
+		return
+	}
+	secret = "whsec_" + secret
+
+	subscription, err := cfg.dbQueries.CreateWebhookSubscription(context.Background(), database.CreateWebhookSubscriptionParams{
+		UserID: caller.UserID,
+		Url:    params.URL,
+		Secret: secret,
+		Events: params.Events,
+	})
+	if err != nil {
+		respondWithError(w, 400, "Couldn't create webhook")
+		return
+	}
+
+	jsonSubscription := transcribeWebhookSubscription(subscription)
+	jsonSubscription.Secret = secret
+	respondWithJson(w, 201, jsonSubscription)
+}
+
+func (cfg *apiConfig) handlerGetWebhooks(w http.ResponseWriter, r *http.Request) {
+	caller, _ := principalFromContext(r.Context())
+
+	results, err := cfg.dbQueries.GetWebhookSubscriptionsForUser(context.Background(), caller.UserID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get webhooks")
+		return
+	}
+	subscriptions := []WebhookSubscription{}
+	for _, result := range results {
+		subscriptions = append(subscriptions, transcribeWebhookSubscription(result))
+	}
+	respondWithJson(w, 200, subscriptions)
+}
+
+func (cfg *apiConfig) handlerDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	caller, _ := principalFromContext(r.Context())
+
+	webhookID, err := uuid.Parse(r.PathValue("webhookID"))
+	if err != nil {
+		respondWithError(w, 400, "invalid webhook id")
+		return
+	}
+
+	deleted, err := cfg.dbQueries.DeleteWebhookSubscription(context.Background(), database.DeleteWebhookSubscriptionParams{
+		ID:     webhookID,
+		UserID: caller.UserID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't delete webhook")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, 404, "couldn't find webhook")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+// ownWebhookSubscription loads the subscription in the request path and
+// checks that it belongs to the caller.
+func (cfg *apiConfig) ownWebhookSubscription(w http.ResponseWriter, r *http.Request) (database.WebhookSubscription, bool) {
+	caller, _ := principalFromContext(r.Context())
+
+	webhookID, err := uuid.Parse(r.PathValue("webhookID"))
+	if err != nil {
+		respondWithError(w, 400, "invalid webhook id")
+		return database.WebhookSubscription{}, false
+	}
+	subscription, err := cfg.dbQueries.GetWebhookSubscription(context.Background(), webhookID)
+	if err != nil || subscription.UserID != caller.UserID {
+		respondWithError(w, 404, "couldn't find webhook")
+		return database.WebhookSubscription{}, false
+	}
+	return subscription, true
+}
+
+func (cfg *apiConfig) handlerGetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	subscription, ok := cfg.ownWebhookSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	results, err := cfg.dbQueries.GetWebhookDeliveries(context.Background(), subscription.ID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get deliveries")
+		return
+	}
+	deliveries := []WebhookDelivery{}
+	for _, result := range results {
+		deliveries = append(deliveries, transcribeWebhookDelivery(result))
+	}
+	respondWithJson(w, 200, deliveries)
+}
+
+func (cfg *apiConfig) handlerRedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	subscription, ok := cfg.ownWebhookSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := uuid.Parse(r.PathValue("deliveryID"))
+	if err != nil {
+		respondWithError(w, 400, "invalid delivery id")
+		return
+	}
+	delivery, err := cfg.dbQueries.GetWebhookDelivery(context.Background(), deliveryID)
+	if err != nil || delivery.SubscriptionID != subscription.ID {
+		respondWithError(w, 404, "couldn't find delivery")
+		return
+	}
+
+	delivery, err = cfg.dbQueries.RedeliverWebhookDelivery(context.Background(), delivery.ID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't queue delivery")
+		return
+	}
+	respondWithJson(w, 202, transcribeWebhookDelivery(delivery))
+}
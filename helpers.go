@@ -3,16 +3,18 @@ package main
 import(
 	"net/http"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"strings"
 )
 
 func respondWithError(w http.ResponseWriter, code int, msg string) {
 	type errorResponse struct {
 		ErrorMessage string `json:"error"`
+		RequestID string `json:"request_id,omitempty"`
 	}
 	respError := errorResponse{
 		ErrorMessage: msg,
+		RequestID: w.Header().Get(requestIDHeader),
 	}
 	respondWithJson(w, code, respError)
 }
@@ -20,7 +22,7 @@ func respondWithError(w http.ResponseWriter, code int, msg string) {
 func respondWithJson(w http.ResponseWriter, code int, payload interface{}) {
 	dat, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Error marshalling JSON: %s", err)
+		slog.Error("couldn't marshal JSON response", "error", err)
 		w.WriteHeader(500)
 		return
 	}
@@ -41,4 +43,18 @@ func replaceProfaneWords(text string) string {
 		}
 	}
 	return strings.Join(words, " ")
-}
\ No newline at end of file
+}
+
+var redactedHeaders = []string{"Authorization", "Cookie"}
+
+// redactHeaders returns a copy of header that is safe to store or log, with
+// credentials replaced.
+func redactHeaders(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, name := range redactedHeaders {
+		if redacted.Get(name) != "" {
+			redacted.Set(name, "[REDACTED]")
+		}
+	}
+	return redacted
+}
@@ -6,6 +6,7 @@ import(
 	"strings"
 	"net/http"
 	"encoding/hex"
+	"crypto/sha256"
 	"github.com/google/uuid"
 	"github.com/alexedwards/argon2id"
 	"github.com/golang-jwt/jwt/v5"
@@ -13,13 +14,31 @@ import(
 )
 
 func HashPassword(password string) (string, error) {
-	hashedPassword, err := argon2id.CreateHash(password, argon2id.DefaultParams)
+	return HashPasswordWithParams(password, argon2id.DefaultParams)
+}
+
+func HashPasswordWithParams(password string, params *argon2id.Params) (string, error) {
+	hashedPassword, err := argon2id.CreateHash(password, params)
 	if err != nil{
 		return "", err
 	}
 	return hashedPassword, nil
 }
 
+// PasswordNeedsRehash reports whether hash was created with parameters
+// weaker than params, so it should be replaced on the next successful login.
+func PasswordNeedsRehash(hash string, params *argon2id.Params) (bool, error) {
+	hashParams, _, _, err := argon2id.DecodeHash(hash)
+	if err != nil {
+		return false, err
+	}
+	return hashParams.Memory < params.Memory ||
+		hashParams.Iterations < params.Iterations ||
+		hashParams.Parallelism < params.Parallelism ||
+		hashParams.SaltLength < params.SaltLength ||
+		hashParams.KeyLength < params.KeyLength, nil
+}
+
 func CheckPasswordHash(password, hash string) (bool, error) {
 	value, err := argon2id.ComparePasswordAndHash(password, hash)
 	if err != nil {
@@ -43,30 +62,17 @@ func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
 	return result, nil
 }
 
+// ValidateJWT validates a first-party access token. Tokens issued to OAuth
+// clients are rejected; use ParseAccessToken to accept those too.
 func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, 
-		func(token *jwt.Token) (interface{}, error) {return []byte(tokenSecret), nil})
-
+	token, err := ParseAccessToken(tokenString, tokenSecret)
 	if err != nil {
 		return uuid.UUID{}, err
 	}
-
-	if !token.Valid {
-		return uuid.UUID{}, errors.New("invalid token")
+	if token.Scoped {
+		return uuid.UUID{}, errors.New("token was issued to an oauth client")
 	}
-
-	claims, ok := token.Claims.(*jwt.RegisteredClaims)
-	if !ok {
-		return uuid.UUID{}, errors.New("couldn't get claims")
-	}
-	
-	idStr := claims.Subject
-
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		return uuid.UUID{}, err
-	}
-	return id, nil	
+	return token.UserID, nil
 }
 
 func GetBearerToken(headers http.Header) (string, error) {
@@ -98,4 +104,56 @@ func GetAPIKey(headers http.Header) (string, error) {
 	}
 
 	return splitAuth[1], nil
-}
\ No newline at end of file
+}
+
+const PersonalAccessTokenPrefix = "chirpy_pat_"
+
+const (
+	ScopeChirpsRead     = "chirps:read"
+	ScopeChirpsWrite    = "chirps:write"
+	ScopeProfileRead    = "profile:read"
+	ScopeProfileWrite   = "profile:write"
+	ScopeWebhooksManage = "webhooks:manage"
+)
+
+var validScopes = []string{ScopeChirpsRead, ScopeChirpsWrite, ScopeProfileRead, ScopeProfileWrite, ScopeWebhooksManage}
+
+func ValidScope(scope string) bool {
+	for _, s := range validScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// MakePersonalAccessToken returns a random token carrying the
+// PersonalAccessTokenPrefix, so it can be told apart from a JWT.
+func MakePersonalAccessToken() (string, error) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", err
+	}
+	return PersonalAccessTokenPrefix + hex.EncodeToString(key), nil
+}
+
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+// HashToken returns the hex-encoded SHA-256 of token. Tokens are random and
+// high entropy, so a fast hash is enough to store them at rest.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
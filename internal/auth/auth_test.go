@@ -5,6 +5,7 @@ import (
 	"time"
 	"net/http"
 	"github.com/google/uuid"
+	"github.com/alexedwards/argon2id"
 )
 
 func TestMakeAndValidateJWT(t *testing.T) {
@@ -139,6 +140,76 @@ func TestCheckPasswordHash(t *testing.T) {
 	}
 }
 
+func TestPasswordNeedsRehash(t *testing.T) {
+	weak := &argon2id.Params{
+		Memory:      16 * 1024,
+		Iterations:  1,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+	strong := &argon2id.Params{
+		Memory:      32 * 1024,
+		Iterations:  2,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+	weakHash, err := HashPasswordWithParams("hunter2", weak)
+	if err != nil {
+		t.Fatalf("HashPasswordWithParams() error = %v", err)
+	}
+	strongHash, err := HashPasswordWithParams("hunter2", strong)
+	if err != nil {
+		t.Fatalf("HashPasswordWithParams() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		hash       string
+		params     *argon2id.Params
+		wantErr    bool
+		wantRehash bool
+	}{
+		{
+			name:       "weak hash against strong params",
+			hash:       weakHash,
+			params:     strong,
+			wantRehash: true,
+		},
+		{
+			name:       "strong hash against strong params",
+			hash:       strongHash,
+			params:     strong,
+			wantRehash: false,
+		},
+		{
+			name:       "strong hash against weak params",
+			hash:       strongHash,
+			params:     weak,
+			wantRehash: false,
+		},
+		{
+			name:    "invalid hash",
+			hash:    "unset",
+			params:  strong,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rehash, err := PasswordNeedsRehash(tc.hash, tc.params)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("error = %v, wantErr = %v", err, tc.wantErr)
+			}
+			if rehash != tc.wantRehash {
+				t.Fatalf("rehash = %v, wantRehash = %v", rehash, tc.wantRehash)
+			}
+		})
+	}
+}
+
 func TestGetBearerToken(t *testing.T) {
 	header := http.Header{}
 	expectedToken := "thisisthetoken"
@@ -156,4 +227,139 @@ func TestGetBearerToken(t *testing.T) {
 	if expectedToken != token {
 		t.Fatalf("Token not correct")
 	}
-}
\ No newline at end of file
+}
+
+func TestMakePersonalAccessToken(t *testing.T) {
+	token, err := MakePersonalAccessToken()
+	if err != nil {
+		t.Fatalf("MakePersonalAccessToken() error = %v", err)
+	}
+	if !IsPersonalAccessToken(token) {
+		t.Fatalf("token %q missing prefix %q", token, PersonalAccessTokenPrefix)
+	}
+	if HashToken(token) == token {
+		t.Fatalf("HashToken() returned the token unchanged")
+	}
+	if HashToken(token) != HashToken(token) {
+		t.Fatalf("HashToken() is not deterministic")
+	}
+
+	jwtToken, err := MakeJWT(uuid.New(), "secret", time.Minute)
+	if err != nil {
+		t.Fatalf("MakeJWT() error = %v", err)
+	}
+	if IsPersonalAccessToken(jwtToken) {
+		t.Fatalf("JWT detected as personal access token")
+	}
+}
+
+func TestScopedJWT(t *testing.T) {
+	userID := uuid.New()
+	clientID := uuid.New()
+	scopes := []string{ScopeChirpsRead}
+
+	tokenString, err := MakeScopedJWT(userID, "secret", time.Minute, clientID, scopes)
+	if err != nil {
+		t.Fatalf("MakeScopedJWT() error = %v", err)
+	}
+
+	token, err := ParseAccessToken(tokenString, "secret")
+	if err != nil {
+		t.Fatalf("ParseAccessToken() error = %v", err)
+	}
+	if token.UserID != userID || token.ClientID != clientID || !token.Scoped {
+		t.Fatalf("ParseAccessToken() = %+v", token)
+	}
+	if !token.HasScope(ScopeChirpsRead) {
+		t.Fatalf("expected scope %s", ScopeChirpsRead)
+	}
+	if token.HasScope(ScopeChirpsWrite) {
+		t.Fatalf("unexpected scope %s", ScopeChirpsWrite)
+	}
+
+	_, err = ValidateJWT(tokenString, "secret")
+	if err == nil {
+		t.Fatalf("ValidateJWT() accepted a token issued to an oauth client")
+	}
+}
+
+func TestVerifyPKCE(t *testing.T) {
+	// Example from RFC 7636 appendix B.
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	if !VerifyPKCE(verifier, challenge) {
+		t.Fatalf("VerifyPKCE() rejected the RFC 7636 example")
+	}
+	if VerifyPKCE(verifier+"x", challenge) {
+		t.Fatalf("VerifyPKCE() accepted a wrong verifier")
+	}
+	if VerifyPKCE("short", challenge) {
+		t.Fatalf("VerifyPKCE() accepted a too short verifier")
+	}
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"event":"user.upgraded"}`)
+	secret := "whsec_test"
+	now := time.Now()
+	header := SignWebhookPayload(body, secret, now)
+
+	tests := []struct {
+		name    string
+		header  string
+		body    []byte
+		secret  string
+		now     time.Time
+		wantErr bool
+	}{
+		{
+			name:   "valid signature",
+			header: header,
+			body:   body,
+			secret: secret,
+			now:    now,
+		},
+		{
+			name:    "tampered body",
+			header:  header,
+			body:    []byte(`{"event":"user.downgraded"}`),
+			secret:  secret,
+			now:     now,
+			wantErr: true,
+		},
+		{
+			name:    "wrong secret",
+			header:  header,
+			body:    body,
+			secret:  "whsec_other",
+			now:     now,
+			wantErr: true,
+		},
+		{
+			name:    "replayed too late",
+			header:  header,
+			body:    body,
+			secret:  secret,
+			now:     now.Add(10 * time.Minute),
+			wantErr: true,
+		},
+		{
+			name:    "malformed header",
+			header:  "v1=abc",
+			body:    body,
+			secret:  secret,
+			now:     now,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := VerifyWebhookSignature(tc.header, tc.body, tc.secret, 5*time.Minute, tc.now)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("error = %v, wantErr = %v", err, tc.wantErr)
+			}
+		})
+	}
+}
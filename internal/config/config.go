@@ -0,0 +1,303 @@
+// Package config loads and validates the server configuration.
+//
+// Values come from, in increasing order of precedence, a .env file in the
+// working directory, the file named by CONFIG_FILE (same KEY=value format)
+// and the process environment.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/joho/godotenv"
+)
+
+const (
+	PlatformDev  = "dev"
+	PlatformProd = "prod"
+
+	DBDriverPostgres = "postgres"
+	DBDriverSQLite   = "sqlite"
+
+	// MinSecretLength is the shortest accepted JWT signing secret.
+	MinSecretLength = 32
+)
+
+// SMTP holds the outgoing mail settings. Mail is only sent when Addr is set.
+type SMTP struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+}
+
+type Config struct {
+	Platform   string
+	ListenAddr string
+	LogLevel   slog.Level
+
+	// DBDriver is picked from the DB_URL scheme: postgres:// (or
+	// postgresql://) selects Postgres and sqlite: selects SQLite.
+	DBDriver          string
+	DBURL             string
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	// AutoMigrate applies pending migrations before the server starts.
+	AutoMigrate bool
+
+	Secret             string
+	PolkaKey           string
+	PolkaWebhookSecret string
+	// BootstrapAdminEmail names an existing account to promote to admin
+	// at startup.
+	BootstrapAdminEmail string
+
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
+
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	MagicLinkTTL    time.Duration
+
+	// AccountDeletionGracePeriod is how long a deleted account can still be
+	// restored before it is purged.
+	AccountDeletionGracePeriod time.Duration
+	// DataExportTTL is how long a finished data export stays downloadable.
+	DataExportTTL time.Duration
+
+	PasswordParams *argon2id.Params
+
+	SMTP         SMTP
+	MagicLinkURL string
+
+	SubscriptionExpiryInterval time.Duration
+	WebhookDeliveryInterval    time.Duration
+	AccountPurgeInterval       time.Duration
+	DataExportInterval         time.Duration
+}
+
+// Load reads .env, CONFIG_FILE and the environment and validates the result.
+// A missing .env is not an error; a missing CONFIG_FILE is.
+func Load() (*Config, error) {
+	values := map[string]string{}
+
+	dotenv, err := godotenv.Read(".env")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("config: reading .env: %w", err)
+	}
+	for k, v := range dotenv {
+		values[k] = v
+	}
+
+	path := os.Getenv("CONFIG_FILE")
+	if path == "" {
+		path = dotenv["CONFIG_FILE"]
+	}
+	if path != "" {
+		file, err := godotenv.Read(path)
+		if err != nil {
+			return nil, fmt.Errorf("config: reading CONFIG_FILE: %w", err)
+		}
+		for k, v := range file {
+			values[k] = v
+		}
+	}
+
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		values[k] = v
+	}
+
+	return Parse(values)
+}
+
+// Parse builds a Config from key/value pairs, applying defaults for unset
+// keys. All problems are reported together rather than one at a time.
+func Parse(values map[string]string) (*Config, error) {
+	p := parser{values: values}
+	cfg := &Config{}
+
+	cfg.Platform = p.string("PLATFORM", PlatformProd)
+	if cfg.Platform != PlatformDev && cfg.Platform != PlatformProd {
+		p.errorf("PLATFORM", "must be %q or %q", PlatformDev, PlatformProd)
+	}
+
+	cfg.ListenAddr = p.string("LISTEN_ADDR", "")
+	if cfg.ListenAddr == "" {
+		port := p.int("PORT", 8080, 1, 65535)
+		cfg.ListenAddr = ":" + strconv.Itoa(port)
+	} else if _, _, err := net.SplitHostPort(cfg.ListenAddr); err != nil {
+		p.errorf("LISTEN_ADDR", "must be host:port: %v", err)
+	}
+
+	if v := p.string("LOG_LEVEL", ""); v != "" {
+		err := cfg.LogLevel.UnmarshalText([]byte(v))
+		if err != nil {
+			p.errorf("LOG_LEVEL", "must be debug, info, warn or error")
+		}
+	}
+
+	cfg.DBURL = p.required("DB_URL")
+	if cfg.DBURL != "" {
+		u, err := url.Parse(cfg.DBURL)
+		switch {
+		case err != nil:
+			p.errorf("DB_URL", "must be a postgres:// or sqlite: URL")
+		case u.Scheme == "postgres" || u.Scheme == "postgresql":
+			cfg.DBDriver = DBDriverPostgres
+		case u.Scheme == "sqlite" && (u.Opaque != "" || u.Path != ""):
+			cfg.DBDriver = DBDriverSQLite
+		default:
+			p.errorf("DB_URL", "must be a postgres:// or sqlite: URL")
+		}
+	}
+	cfg.DBMaxOpenConns = p.int("DB_MAX_OPEN_CONNS", 10, 0, 10000)
+	cfg.DBMaxIdleConns = p.int("DB_MAX_IDLE_CONNS", 5, 0, 10000)
+	if cfg.DBMaxOpenConns > 0 && cfg.DBMaxIdleConns > cfg.DBMaxOpenConns {
+		p.errorf("DB_MAX_IDLE_CONNS", "must not exceed DB_MAX_OPEN_CONNS")
+	}
+	cfg.DBConnMaxLifetime = p.duration("DB_CONN_MAX_LIFETIME", 30*time.Minute)
+	cfg.AutoMigrate = p.bool("AUTO_MIGRATE", false)
+
+	cfg.Secret = p.required("SECRET")
+	if cfg.Secret != "" && len(cfg.Secret) < MinSecretLength {
+		p.errorf("SECRET", "must be at least %d characters", MinSecretLength)
+	}
+	cfg.PolkaKey = p.required("POLKA_KEY")
+	cfg.PolkaWebhookSecret = p.string("POLKA_WEBHOOK_SECRET", "")
+	if cfg.PolkaWebhookSecret == "" && cfg.Platform == PlatformProd {
+		p.errorf("POLKA_WEBHOOK_SECRET", "is required on the %s platform", PlatformProd)
+	}
+	cfg.BootstrapAdminEmail = p.string("BOOTSTRAP_ADMIN_EMAIL", "")
+	if cfg.BootstrapAdminEmail != "" && !strings.Contains(cfg.BootstrapAdminEmail, "@") {
+		p.errorf("BOOTSTRAP_ADMIN_EMAIL", "must be an email address")
+	}
+
+	cfg.ReadTimeout = p.duration("SERVER_READ_TIMEOUT", 15*time.Second)
+	cfg.ReadHeaderTimeout = p.duration("SERVER_READ_HEADER_TIMEOUT", 5*time.Second)
+	cfg.WriteTimeout = p.duration("SERVER_WRITE_TIMEOUT", 30*time.Second)
+	cfg.IdleTimeout = p.duration("SERVER_IDLE_TIMEOUT", 120*time.Second)
+	cfg.ShutdownTimeout = p.duration("SHUTDOWN_TIMEOUT", 30*time.Second)
+
+	cfg.AccessTokenTTL = p.duration("ACCESS_TOKEN_TTL", time.Hour)
+	cfg.RefreshTokenTTL = p.duration("REFRESH_TOKEN_TTL", 60*24*time.Hour)
+	cfg.MagicLinkTTL = p.duration("MAGIC_LINK_TTL", 15*time.Minute)
+	cfg.AccountDeletionGracePeriod = p.duration("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour)
+	cfg.DataExportTTL = p.duration("DATA_EXPORT_TTL", 7*24*time.Hour)
+
+	params := *argon2id.DefaultParams
+	params.Memory = uint32(p.int("ARGON2_MEMORY", int(params.Memory), 8*1024, 4*1024*1024))
+	params.Iterations = uint32(p.int("ARGON2_ITERATIONS", int(params.Iterations), 1, 100))
+	params.Parallelism = uint8(p.int("ARGON2_PARALLELISM", int(params.Parallelism), 1, 255))
+	cfg.PasswordParams = &params
+
+	cfg.SMTP = SMTP{
+		Addr:     p.string("SMTP_ADDR", ""),
+		From:     p.string("SMTP_FROM", ""),
+		Username: p.string("SMTP_USERNAME", ""),
+		Password: p.string("SMTP_PASSWORD", ""),
+	}
+	if cfg.SMTP.Addr != "" && cfg.SMTP.From == "" {
+		p.errorf("SMTP_FROM", "is required when SMTP_ADDR is set")
+	}
+
+	cfg.MagicLinkURL = p.string("MAGIC_LINK_URL", "http://localhost:8080/app/login")
+	if u, err := url.Parse(cfg.MagicLinkURL); err != nil || !u.IsAbs() || u.Host == "" {
+		p.errorf("MAGIC_LINK_URL", "must be an absolute URL")
+	}
+
+	cfg.SubscriptionExpiryInterval = p.duration("SUBSCRIPTION_EXPIRY_INTERVAL", time.Hour)
+	cfg.WebhookDeliveryInterval = p.duration("WEBHOOK_DELIVERY_INTERVAL", 5*time.Second)
+	cfg.AccountPurgeInterval = p.duration("ACCOUNT_PURGE_INTERVAL", time.Hour)
+	cfg.DataExportInterval = p.duration("DATA_EXPORT_INTERVAL", 10*time.Second)
+
+	if len(p.errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(p.errs...))
+	}
+	return cfg, nil
+}
+
+type parser struct {
+	values map[string]string
+	errs   []error
+}
+
+func (p *parser) errorf(key, format string, args ...any) {
+	p.errs = append(p.errs, fmt.Errorf("%s %s", key, fmt.Sprintf(format, args...)))
+}
+
+func (p *parser) string(key, def string) string {
+	v, ok := p.values[key]
+	if !ok || v == "" {
+		return def
+	}
+	return v
+}
+
+func (p *parser) required(key string) string {
+	v := p.values[key]
+	if v == "" {
+		p.errorf(key, "is required")
+	}
+	return v
+}
+
+func (p *parser) int(key string, def, min, max int) int {
+	v := p.values[key]
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		p.errorf(key, "must be an integer, got %q", v)
+		return def
+	}
+	if n < min || n > max {
+		p.errorf(key, "must be between %d and %d, got %d", min, max, n)
+		return def
+	}
+	return n
+}
+
+func (p *parser) bool(key string, def bool) bool {
+	v := p.values[key]
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		p.errorf(key, "must be true or false, got %q", v)
+		return def
+	}
+	return b
+}
+
+func (p *parser) duration(key string, def time.Duration) time.Duration {
+	v := p.values[key]
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		p.errorf(key, "must be a duration such as 30s or 5m, got %q", v)
+		return def
+	}
+	if d <= 0 {
+		p.errorf(key, "must be positive, got %s", v)
+		return def
+	}
+	return d
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func validValues() map[string]string {
+	return map[string]string{
+		"DB_URL":               "postgres://chirpy@localhost:5432/chirpy?sslmode=disable",
+		"SECRET":               strings.Repeat("s", MinSecretLength),
+		"POLKA_KEY":            "f271c81ff7084ee5b99a5091b42d486e",
+		"POLKA_WEBHOOK_SECRET": "whsec_3f9a1c",
+	}
+}
+
+func TestParseDefaults(t *testing.T) {
+	cfg, err := Parse(validValues())
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if cfg.Platform != PlatformProd {
+		t.Errorf("Platform = %q, want %q", cfg.Platform, PlatformProd)
+	}
+	if cfg.ListenAddr != ":8080" {
+		t.Errorf("ListenAddr = %q, want :8080", cfg.ListenAddr)
+	}
+	if cfg.AccessTokenTTL != time.Hour {
+		t.Errorf("AccessTokenTTL = %s, want 1h", cfg.AccessTokenTTL)
+	}
+	if cfg.PasswordParams == nil || cfg.PasswordParams.Iterations == 0 {
+		t.Errorf("PasswordParams = %+v, want argon2id defaults", cfg.PasswordParams)
+	}
+	if cfg.DBDriver != DBDriverPostgres {
+		t.Errorf("DBDriver = %q, want %q", cfg.DBDriver, DBDriverPostgres)
+	}
+}
+
+func TestParseSQLiteURL(t *testing.T) {
+	for _, dbURL := range []string{"sqlite:chirpy.db", "sqlite:///var/lib/chirpy/chirpy.db"} {
+		values := validValues()
+		values["DB_URL"] = dbURL
+		cfg, err := Parse(values)
+		if err != nil {
+			t.Fatalf("Parse(DB_URL=%s) error = %v", dbURL, err)
+		}
+		if cfg.DBDriver != DBDriverSQLite {
+			t.Errorf("DB_URL=%s: DBDriver = %q, want %q", dbURL, cfg.DBDriver, DBDriverSQLite)
+		}
+	}
+}
+
+func TestParseDevWithoutWebhookSecret(t *testing.T) {
+	values := validValues()
+	values["PLATFORM"] = PlatformDev
+	delete(values, "POLKA_WEBHOOK_SECRET")
+	if _, err := Parse(values); err != nil {
+		t.Fatalf("Parse() error = %v, want unsigned webhooks allowed on dev", err)
+	}
+}
+
+func TestParseOverrides(t *testing.T) {
+	values := validValues()
+	values["PORT"] = "9000"
+	values["SERVER_WRITE_TIMEOUT"] = "45s"
+	values["DB_MAX_OPEN_CONNS"] = "20"
+	values["LOG_LEVEL"] = "debug"
+	values["AUTO_MIGRATE"] = "true"
+
+	cfg, err := Parse(values)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if cfg.ListenAddr != ":9000" {
+		t.Errorf("ListenAddr = %q, want :9000", cfg.ListenAddr)
+	}
+	if cfg.WriteTimeout != 45*time.Second {
+		t.Errorf("WriteTimeout = %s, want 45s", cfg.WriteTimeout)
+	}
+	if cfg.DBMaxOpenConns != 20 {
+		t.Errorf("DBMaxOpenConns = %d, want 20", cfg.DBMaxOpenConns)
+	}
+	if !cfg.AutoMigrate {
+		t.Error("AutoMigrate = false, want true")
+	}
+	if cfg.LogLevel.String() != "DEBUG" {
+		t.Errorf("LogLevel = %s, want DEBUG", cfg.LogLevel)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   string
+		wantErr string
+	}{
+		{name: "missing secret", key: "SECRET", value: "", wantErr: "SECRET is required"},
+		{name: "short secret", key: "SECRET", value: "short", wantErr: "SECRET must be at least"},
+		{name: "missing polka key", key: "POLKA_KEY", value: "", wantErr: "POLKA_KEY is required"},
+		{name: "prod without webhook secret", key: "POLKA_WEBHOOK_SECRET", value: "", wantErr: "POLKA_WEBHOOK_SECRET is required on the prod platform"},
+		{name: "unsupported db url", key: "DB_URL", value: "mysql://localhost/chirpy", wantErr: "DB_URL must be"},
+		{name: "sqlite url without path", key: "DB_URL", value: "sqlite:", wantErr: "DB_URL must be"},
+		{name: "bad bool", key: "AUTO_MIGRATE", value: "sometimes", wantErr: "AUTO_MIGRATE must be true or false"},
+		{name: "bad duration", key: "SERVER_READ_TIMEOUT", value: "soon", wantErr: "SERVER_READ_TIMEOUT must be a duration"},
+		{name: "port out of range", key: "PORT", value: "70000", wantErr: "PORT must be between"},
+		{name: "unknown platform", key: "PLATFORM", value: "staging", wantErr: "PLATFORM must be"},
+		{name: "bad bootstrap admin", key: "BOOTSTRAP_ADMIN_EMAIL", value: "admin", wantErr: "BOOTSTRAP_ADMIN_EMAIL must be an email address"},
+		{name: "smtp without sender", key: "SMTP_ADDR", value: "smtp.example.com:587", wantErr: "SMTP_FROM is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := validValues()
+			values[tt.key] = tt.value
+			_, err := Parse(values)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	path := filepath.Join(dir, "chirpy.env")
+	contents := "DB_URL=postgres://localhost/chirpy\nSECRET=" + strings.Repeat("x", MinSecretLength) + "\nPOLKA_KEY=key\nPOLKA_WEBHOOK_SECRET=whsec\nPORT=9100\n"
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("PORT", "9200")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.ListenAddr != ":9200" {
+		t.Errorf("ListenAddr = %q, want the environment to win over CONFIG_FILE", cfg.ListenAddr)
+	}
+}
@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
@@ -20,7 +21,7 @@ VALUES (
     $1,
     $2
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, suspended_until, shadowbanned, deletion_scheduled_at
 `
 
 type CreateUserParams struct {
@@ -38,10 +39,39 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.Shadowbanned,
+		&i.DeletionScheduledAt,
 	)
 	return i, err
 }
 
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, id)
+	return err
+}
+
+const deleteUserIfDue = `-- name: DeleteUserIfDue :execrows
+DELETE FROM users
+WHERE id = $1 AND deletion_scheduled_at <= NOW()
+`
+
+// Leaves the account alone if its deletion was cancelled after it was
+// picked up by GetUsersDueForDeletion.
+func (q *Queries) DeleteUserIfDue(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserIfDue, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteUsers = `-- name: DeleteUsers :exec
 DELETE FROM users
 `
@@ -52,7 +82,7 @@ func (q *Queries) DeleteUsers(ctx context.Context) error {
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, suspended_until, shadowbanned, deletion_scheduled_at FROM users
 WHERE email = $1
 `
 
@@ -66,6 +96,135 @@ func (q *Queries) GetUser(ctx context.Context, email string) (User, error) {
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.Shadowbanned,
+		&i.DeletionScheduledAt,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, suspended_until, shadowbanned, deletion_scheduled_at FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.Shadowbanned,
+		&i.DeletionScheduledAt,
+	)
+	return i, err
+}
+
+const getUserPasswordHashes = `-- name: GetUserPasswordHashes :many
+SELECT id, hashed_password FROM users
+`
+
+type GetUserPasswordHashesRow struct {
+	ID             uuid.UUID
+	HashedPassword string
+}
+
+func (q *Queries) GetUserPasswordHashes(ctx context.Context) ([]GetUserPasswordHashesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserPasswordHashes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserPasswordHashesRow
+	for rows.Next() {
+		var i GetUserPasswordHashesRow
+		if err := rows.Scan(&i.ID, &i.HashedPassword); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUsersDueForDeletion = `-- name: GetUsersDueForDeletion :many
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, suspended_until, shadowbanned, deletion_scheduled_at FROM users
+WHERE deletion_scheduled_at <= NOW()
+ORDER BY deletion_scheduled_at
+`
+
+func (q *Queries) GetUsersDueForDeletion(ctx context.Context) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersDueForDeletion)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.Role,
+			&i.SuspendedUntil,
+			&i.Shadowbanned,
+			&i.DeletionScheduledAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const scheduleUserDeletion = `-- name: ScheduleUserDeletion :one
+UPDATE users
+SET deletion_scheduled_at = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, suspended_until, shadowbanned, deletion_scheduled_at
+`
+
+type ScheduleUserDeletionParams struct {
+	ID                  uuid.UUID
+	DeletionScheduledAt sql.NullTime
+}
+
+func (q *Queries) ScheduleUserDeletion(ctx context.Context, arg ScheduleUserDeletionParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, scheduleUserDeletion, arg.ID, arg.DeletionScheduledAt)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.Shadowbanned,
+		&i.DeletionScheduledAt,
 	)
 	return i, err
 }
@@ -74,7 +233,7 @@ const setUserChirpyRed = `-- name: SetUserChirpyRed :one
 UPDATE users
 SET is_chirpy_red = TRUE
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, suspended_until, shadowbanned, deletion_scheduled_at
 `
 
 func (q *Queries) SetUserChirpyRed(ctx context.Context, id uuid.UUID) (User, error) {
@@ -87,15 +246,35 @@ func (q *Queries) SetUserChirpyRed(ctx context.Context, id uuid.UUID) (User, err
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.Shadowbanned,
+		&i.DeletionScheduledAt,
 	)
 	return i, err
 }
 
+const setUserChirpyRedStatus = `-- name: SetUserChirpyRedStatus :exec
+UPDATE users
+SET is_chirpy_red = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetUserChirpyRedStatusParams struct {
+	ID          uuid.UUID
+	IsChirpyRed bool
+}
+
+func (q *Queries) SetUserChirpyRedStatus(ctx context.Context, arg SetUserChirpyRedStatusParams) error {
+	_, err := q.db.ExecContext(ctx, setUserChirpyRedStatus, arg.ID, arg.IsChirpyRed)
+	return err
+}
+
 const setUserEmailPassword = `-- name: SetUserEmailPassword :one
 UPDATE users
 SET email = $1, hashed_password = $2
 WHERE id = $3
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, suspended_until, shadowbanned, deletion_scheduled_at
 `
 
 type SetUserEmailPasswordParams struct {
@@ -114,6 +293,116 @@ func (q *Queries) SetUserEmailPassword(ctx context.Context, arg SetUserEmailPass
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.Shadowbanned,
+		&i.DeletionScheduledAt,
+	)
+	return i, err
+}
+
+const setUserPassword = `-- name: SetUserPassword :exec
+UPDATE users
+SET hashed_password = $1, updated_at = NOW()
+WHERE id = $2
+`
+
+type SetUserPasswordParams struct {
+	HashedPassword string
+	ID             uuid.UUID
+}
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, setUserPassword, arg.HashedPassword, arg.ID)
+	return err
+}
+
+const setUserRole = `-- name: SetUserRole :one
+UPDATE users
+SET role = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, suspended_until, shadowbanned, deletion_scheduled_at
+`
+
+type SetUserRoleParams struct {
+	ID   uuid.UUID
+	Role string
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserRole, arg.ID, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.Shadowbanned,
+		&i.DeletionScheduledAt,
+	)
+	return i, err
+}
+
+const setUserShadowbanned = `-- name: SetUserShadowbanned :one
+UPDATE users
+SET shadowbanned = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, suspended_until, shadowbanned, deletion_scheduled_at
+`
+
+type SetUserShadowbannedParams struct {
+	ID           uuid.UUID
+	Shadowbanned bool
+}
+
+func (q *Queries) SetUserShadowbanned(ctx context.Context, arg SetUserShadowbannedParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserShadowbanned, arg.ID, arg.Shadowbanned)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.Shadowbanned,
+		&i.DeletionScheduledAt,
+	)
+	return i, err
+}
+
+const suspendUser = `-- name: SuspendUser :one
+UPDATE users
+SET suspended_until = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, suspended_until, shadowbanned, deletion_scheduled_at
+`
+
+type SuspendUserParams struct {
+	ID             uuid.UUID
+	SuspendedUntil sql.NullTime
+}
+
+func (q *Queries) SuspendUser(ctx context.Context, arg SuspendUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, suspendUser, arg.ID, arg.SuspendedUntil)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.Shadowbanned,
+		&i.DeletionScheduledAt,
 	)
 	return i, err
 }
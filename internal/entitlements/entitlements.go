@@ -0,0 +1,48 @@
+package entitlements
+
+type Plan string
+
+const (
+	PlanFree      Plan = "free"
+	PlanChirpyRed Plan = "chirpy_red"
+)
+
+// Entitlements are the capabilities a plan grants. Handlers consult these
+// instead of checking the plan directly.
+type Entitlements struct {
+	Plan           Plan `json:"plan"`
+	MaxChirpLength int  `json:"max_chirp_length"`
+	CanEditChirps  bool `json:"can_edit_chirps"`
+	ChirpsPerHour  int  `json:"chirps_per_hour"`
+}
+
+var plans = map[Plan]Entitlements{
+	PlanFree: {
+		Plan:           PlanFree,
+		MaxChirpLength: 140,
+		CanEditChirps:  false,
+		ChirpsPerHour:  30,
+	},
+	PlanChirpyRed: {
+		Plan:           PlanChirpyRed,
+		MaxChirpLength: 280,
+		CanEditChirps:  true,
+		ChirpsPerHour:  300,
+	},
+}
+
+// For returns the entitlements of plan. Unknown plans get the free tier.
+func For(plan Plan) Entitlements {
+	e, ok := plans[plan]
+	if !ok {
+		return plans[PlanFree]
+	}
+	return e
+}
+
+func PlanFor(isChirpyRed bool) Plan {
+	if isChirpyRed {
+		return PlanChirpyRed
+	}
+	return PlanFree
+}
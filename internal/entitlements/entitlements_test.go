@@ -0,0 +1,47 @@
+package entitlements
+
+import "testing"
+
+func TestFor(t *testing.T) {
+	tests := []struct {
+		name        string
+		plan        Plan
+		wantPlan    Plan
+		wantEditing bool
+	}{
+		{
+			name:        "free plan",
+			plan:        PlanFree,
+			wantPlan:    PlanFree,
+			wantEditing: false,
+		},
+		{
+			name:        "chirpy red plan",
+			plan:        PlanChirpyRed,
+			wantPlan:    PlanChirpyRed,
+			wantEditing: true,
+		},
+		{
+			name:        "unknown plan falls back to free",
+			plan:        Plan("enterprise"),
+			wantPlan:    PlanFree,
+			wantEditing: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			e := For(tc.plan)
+			if e.Plan != tc.wantPlan {
+				t.Fatalf("Plan = %v, want %v", e.Plan, tc.wantPlan)
+			}
+			if e.CanEditChirps != tc.wantEditing {
+				t.Fatalf("CanEditChirps = %v, want %v", e.CanEditChirps, tc.wantEditing)
+			}
+		})
+	}
+
+	if For(PlanChirpyRed).MaxChirpLength <= For(PlanFree).MaxChirpLength {
+		t.Fatalf("chirpy red should allow longer chirps than free")
+	}
+}
@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestBuildMessage(t *testing.T) {
+	msg := buildMessage("chirpy@example.com", Message{
+		To:      "user@example.com",
+		Subject: "Hello",
+		Body:    "line one\nline two",
+	})
+
+	headers, body, ok := strings.Cut(string(msg), "\r\n\r\n")
+	if !ok {
+		t.Fatalf("message has no header/body separator: %q", msg)
+	}
+	for _, want := range []string{
+		"From: chirpy@example.com",
+		"To: user@example.com",
+		"Subject: Hello",
+	} {
+		if !strings.Contains(headers, want) {
+			t.Fatalf("headers %q missing %q", headers, want)
+		}
+	}
+	if body != "line one\r\nline two" {
+		t.Fatalf("body = %q", body)
+	}
+}
+
+func TestLogMailerLeavesOutBody(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	msg := Message{To: "user@example.com", Subject: "Login", Body: "token=secret"}
+	if err := (LogMailer{}).Send(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "secret") {
+		t.Errorf("log contains the message body: %s", buf.String())
+	}
+	if err := (LogMailer{IncludeBody: true}).Send(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "secret") {
+		t.Errorf("log is missing the body with IncludeBody set: %s", buf.String())
+	}
+}
@@ -0,0 +1,50 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateAuditEvent(ctx context.Context, arg database.CreateAuditEventParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.auditEvents = append(s.auditEvents, database.AuditEvent{
+		ID:          uuid.New(),
+		CreatedAt:   time.Now(),
+		ActorID:     arg.ActorID,
+		Action:      arg.Action,
+		TargetType:  arg.TargetType,
+		TargetID:    arg.TargetID,
+		Ip:          arg.Ip,
+		RequestID:   arg.RequestID,
+		BeforeState: arg.BeforeState,
+		AfterState:  arg.AfterState,
+	})
+	return nil
+}
+
+func (s *Store) ListAuditEvents(ctx context.Context, arg database.ListAuditEventsParams) ([]database.AuditEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := filter(s.auditEvents, func(e database.AuditEvent) bool {
+		return (!arg.ActorID.Valid || e.ActorID == arg.ActorID) &&
+			(!arg.TargetID.Valid || e.TargetID == arg.TargetID) &&
+			(!arg.Since.Valid || !e.CreatedAt.Before(arg.Since.Time)) &&
+			(!arg.Until.Valid || e.CreatedAt.Before(arg.Until.Time))
+	})
+	slices.Reverse(items)
+	slices.SortStableFunc(items, func(a, b database.AuditEvent) int { return b.CreatedAt.Compare(a.CreatedAt) })
+
+	start := min(int(arg.Offset), len(items))
+	end := min(start+int(arg.Limit), len(items))
+	if start == end {
+		return nil, nil
+	}
+	return items[start:end], nil
+}
@@ -0,0 +1,129 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CountChirpsByUserSince(ctx context.Context, arg database.CountChirpsByUserSinceParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	for _, c := range s.chirps {
+		if c.UserID == arg.UserID && c.CreatedAt.After(arg.CreatedAt) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *Store) CreateChirp(ctx context.Context, arg database.CreateChirpParams) (database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.userExists(arg.UserID) {
+		return database.Chirp{}, ErrForeignKeyViolation
+	}
+	now := time.Now()
+	chirp := database.Chirp{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Body:      arg.Body,
+		UserID:    arg.UserID,
+	}
+	s.chirps = append(s.chirps, chirp)
+	return chirp, nil
+}
+
+func (s *Store) DeleteChirpByID(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.chirps = slices.DeleteFunc(s.chirps, func(c database.Chirp) bool { return c.ID == id })
+	s.detachReports(func(chirpID uuid.UUID) bool { return chirpID == id })
+	return nil
+}
+
+func (s *Store) DeleteChirps(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.chirps = nil
+	s.detachReports(func(uuid.UUID) bool { return true })
+	return nil
+}
+
+func (s *Store) GetChirp(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.chirps, func(c database.Chirp) bool { return c.ID == id })
+	if i < 0 {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return s.chirps[i], nil
+}
+
+func (s *Store) GetChirps(ctx context.Context, viewerID uuid.NullUUID) ([]database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shadowbanned := map[uuid.UUID]bool{}
+	for _, u := range s.users {
+		shadowbanned[u.ID] = u.Shadowbanned
+	}
+	items := filter(s.chirps, func(c database.Chirp) bool {
+		if !viewerID.Valid {
+			return !c.HiddenAt.Valid && !shadowbanned[c.UserID]
+		}
+		viewer := viewerID.UUID
+		if s.blocked(viewer, c.UserID) || s.blocked(c.UserID, viewer) || s.muted(viewer, c.UserID) {
+			return false
+		}
+		return c.UserID == viewer || (!c.HiddenAt.Valid && !shadowbanned[c.UserID])
+	})
+	slices.SortStableFunc(items, func(a, b database.Chirp) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return items, nil
+}
+
+func (s *Store) GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := filter(s.chirps, func(c database.Chirp) bool { return c.UserID == userID })
+	slices.SortStableFunc(items, func(a, b database.Chirp) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return items, nil
+}
+
+func (s *Store) HideChirp(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.chirps, func(c database.Chirp) bool { return c.ID == id })
+	if i >= 0 {
+		now := time.Now()
+		s.chirps[i].HiddenAt = sql.NullTime{Time: now, Valid: true}
+		s.chirps[i].UpdatedAt = now
+	}
+	return nil
+}
+
+func (s *Store) UpdateChirpBody(ctx context.Context, arg database.UpdateChirpBodyParams) (database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.chirps, func(c database.Chirp) bool { return c.ID == arg.ID })
+	if i < 0 {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	s.chirps[i].Body = arg.Body
+	s.chirps[i].UpdatedAt = time.Now()
+	return s.chirps[i], nil
+}
@@ -0,0 +1,102 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) ClaimPendingDataExports(ctx context.Context, limit int32) ([]database.DataExport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	stale := now.Add(-10 * time.Minute)
+	var due []int
+	for i, e := range s.dataExports {
+		if e.Status == "pending" || e.Status == "running" && !e.UpdatedAt.After(stale) {
+			due = append(due, i)
+		}
+	}
+	slices.SortStableFunc(due, func(a, b int) int {
+		return s.dataExports[a].CreatedAt.Compare(s.dataExports[b].CreatedAt)
+	})
+	if len(due) > int(limit) {
+		due = due[:limit]
+	}
+
+	var items []database.DataExport
+	for _, i := range due {
+		e := &s.dataExports[i]
+		e.Status = "running"
+		e.UpdatedAt = now
+		items = append(items, *e)
+	}
+	return items, nil
+}
+
+func (s *Store) CreateDataExport(ctx context.Context, userID uuid.UUID) (database.DataExport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.userExists(userID) {
+		return database.DataExport{}, ErrForeignKeyViolation
+	}
+	now := time.Now()
+	export := database.DataExport{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    userID,
+		Status:    "pending",
+	}
+	s.dataExports = append(s.dataExports, export)
+	return export, nil
+}
+
+func (s *Store) DeleteExpiredDataExports(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	before := len(s.dataExports)
+	s.dataExports = slices.DeleteFunc(s.dataExports, func(e database.DataExport) bool {
+		return e.ExpiresAt.Valid && !e.ExpiresAt.Time.After(now)
+	})
+	return int64(before - len(s.dataExports)), nil
+}
+
+func (s *Store) FinishDataExport(ctx context.Context, arg database.FinishDataExportParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.dataExports, func(e database.DataExport) bool { return e.ID == arg.ID })
+	if i < 0 {
+		return nil
+	}
+	now := time.Now()
+	e := &s.dataExports[i]
+	e.Status = arg.Status
+	e.Archive = slices.Clone(arg.Archive)
+	e.CompletedAt = sql.NullTime{Time: now, Valid: true}
+	e.ExpiresAt = arg.ExpiresAt
+	e.UpdatedAt = now
+	return nil
+}
+
+func (s *Store) GetLatestDataExportForUser(ctx context.Context, userID uuid.UUID) (database.DataExport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Rows are kept in insertion order, so the last match is the newest.
+	for i := len(s.dataExports) - 1; i >= 0; i-- {
+		if s.dataExports[i].UserID == userID {
+			return s.dataExports[i], nil
+		}
+	}
+	return database.DataExport{}, sql.ErrNoRows
+}
@@ -0,0 +1,71 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateInboundWebhook(ctx context.Context, arg database.CreateInboundWebhookParams) (database.InboundWebhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	webhook := database.InboundWebhook{
+		ID:         uuid.New(),
+		ReceivedAt: time.Now(),
+		Source:     arg.Source,
+		Headers:    slices.Clone(arg.Headers),
+		Body:       arg.Body,
+		ReplayOf:   arg.ReplayOf,
+	}
+	s.inboundWebhooks = append(s.inboundWebhooks, webhook)
+	return webhook, nil
+}
+
+func (s *Store) FinishInboundWebhook(ctx context.Context, arg database.FinishInboundWebhookParams) (database.InboundWebhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.inboundWebhooks, func(w database.InboundWebhook) bool { return w.ID == arg.ID })
+	if i < 0 {
+		return database.InboundWebhook{}, sql.ErrNoRows
+	}
+	w := &s.inboundWebhooks[i]
+	w.StatusCode = arg.StatusCode
+	w.Outcome = arg.Outcome
+	w.Error = arg.Error
+	return *w, nil
+}
+
+func (s *Store) GetInboundWebhook(ctx context.Context, id uuid.UUID) (database.InboundWebhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.inboundWebhooks, func(w database.InboundWebhook) bool { return w.ID == id })
+	if i < 0 {
+		return database.InboundWebhook{}, sql.ErrNoRows
+	}
+	return s.inboundWebhooks[i], nil
+}
+
+func (s *Store) ListInboundWebhooks(ctx context.Context, arg database.ListInboundWebhooksParams) ([]database.InboundWebhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := filter(s.inboundWebhooks, func(w database.InboundWebhook) bool {
+		return !arg.Outcome.Valid || w.Outcome == arg.Outcome
+	})
+	slices.Reverse(items)
+	slices.SortStableFunc(items, func(a, b database.InboundWebhook) int { return b.ReceivedAt.Compare(a.ReceivedAt) })
+
+	start := min(int(arg.Offset), len(items))
+	end := min(start+int(arg.Limit), len(items))
+	if start == end {
+		return nil, nil
+	}
+	return items[start:end], nil
+}
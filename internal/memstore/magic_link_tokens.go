@@ -0,0 +1,42 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+)
+
+func (s *Store) ConsumeMagicLinkToken(ctx context.Context, tokenHash string) (database.MagicLinkToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.magicLinkTokens, func(t database.MagicLinkToken) bool {
+		return t.TokenHash == tokenHash && !t.UsedAt.Valid
+	})
+	if i < 0 {
+		return database.MagicLinkToken{}, sql.ErrNoRows
+	}
+	s.magicLinkTokens[i].UsedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	return s.magicLinkTokens[i], nil
+}
+
+func (s *Store) CreateMagicLinkToken(ctx context.Context, arg database.CreateMagicLinkTokenParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.userExists(arg.UserID) {
+		return ErrForeignKeyViolation
+	}
+	if find(s.magicLinkTokens, func(t database.MagicLinkToken) bool { return t.TokenHash == arg.TokenHash }) >= 0 {
+		return ErrUniqueViolation
+	}
+	s.magicLinkTokens = append(s.magicLinkTokens, database.MagicLinkToken{
+		TokenHash: arg.TokenHash,
+		CreatedAt: time.Now(),
+		UserID:    arg.UserID,
+		ExpiresAt: arg.ExpiresAt,
+	})
+	return nil
+}
@@ -0,0 +1,155 @@
+// Package memstore is an in-memory database.Querier for tests that shouldn't
+// need Postgres. It mirrors the behaviour of the SQL queries that handlers
+// rely on: missing rows return sql.ErrNoRows, unique columns are enforced,
+// foreign keys are checked and deleting a user cascades to everything the
+// user owns.
+package memstore
+
+import (
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrUniqueViolation is returned where Postgres would reject a duplicate
+	// value in a unique column.
+	ErrUniqueViolation = errors.New("memstore: duplicate key value violates unique constraint")
+	// ErrForeignKeyViolation is returned where Postgres would reject a row
+	// referencing a missing parent.
+	ErrForeignKeyViolation = errors.New("memstore: insert violates foreign key constraint")
+)
+
+// Store holds every table as a slice in insertion order, which keeps
+// ORDER BY created_at stable for rows created within the same instant.
+type Store struct {
+	mu sync.Mutex
+
+	users                   []database.User
+	chirps                  []database.Chirp
+	refreshTokens           []database.RefreshToken
+	personalAccessTokens    []database.PersonalAccessToken
+	magicLinkTokens         []database.MagicLinkToken
+	oauthClients            []database.OauthClient
+	oauthAuthorizationCodes []database.OauthAuthorizationCode
+	webhookEvents           []database.WebhookEvent
+	subscriptions           []database.Subscription
+	subscriptionEvents      []database.SubscriptionEvent
+	webhookSubscriptions    []database.WebhookSubscription
+	webhookDeliveries       []database.WebhookDelivery
+	inboundWebhooks         []database.InboundWebhook
+	reports                 []database.Report
+	moderationActions       []database.ModerationAction
+	auditEvents             []database.AuditEvent
+	userBlocks              []database.UserBlock
+	userMutes               []database.UserMute
+	dataExports             []database.DataExport
+}
+
+var _ database.Querier = (*Store)(nil)
+
+func New() *Store {
+	return &Store{}
+}
+
+// find returns the index of the first row matching match, or -1.
+func find[T any](rows []T, match func(T) bool) int {
+	return slices.IndexFunc(rows, match)
+}
+
+// filter returns the rows matching match. Like sqlc's :many queries it
+// returns nil rather than an empty slice when nothing matches.
+func filter[T any](rows []T, match func(T) bool) []T {
+	var items []T
+	for _, row := range rows {
+		if match(row) {
+			items = append(items, row)
+		}
+	}
+	return items
+}
+
+func (s *Store) userExists(id uuid.UUID) bool {
+	return find(s.users, func(u database.User) bool { return u.ID == id }) >= 0
+}
+
+// deleteUserData removes the rows that reference the given users, following
+// the ON DELETE CASCADE clauses of the schema.
+func (s *Store) deleteUserData(deleted func(uuid.UUID) bool) {
+	s.chirps = slices.DeleteFunc(s.chirps, func(c database.Chirp) bool { return deleted(c.UserID) })
+	s.refreshTokens = slices.DeleteFunc(s.refreshTokens, func(t database.RefreshToken) bool { return deleted(t.UserID) })
+	s.personalAccessTokens = slices.DeleteFunc(s.personalAccessTokens, func(t database.PersonalAccessToken) bool { return deleted(t.UserID) })
+	s.magicLinkTokens = slices.DeleteFunc(s.magicLinkTokens, func(t database.MagicLinkToken) bool { return deleted(t.UserID) })
+	s.dataExports = slices.DeleteFunc(s.dataExports, func(e database.DataExport) bool { return deleted(e.UserID) })
+
+	var clients []uuid.UUID
+	s.oauthClients = slices.DeleteFunc(s.oauthClients, func(c database.OauthClient) bool {
+		if deleted(c.OwnerID) {
+			clients = append(clients, c.ID)
+			return true
+		}
+		return false
+	})
+	s.oauthAuthorizationCodes = slices.DeleteFunc(s.oauthAuthorizationCodes, func(c database.OauthAuthorizationCode) bool {
+		return deleted(c.UserID) || slices.Contains(clients, c.ClientID)
+	})
+	s.refreshTokens = slices.DeleteFunc(s.refreshTokens, func(t database.RefreshToken) bool {
+		return t.ClientID.Valid && slices.Contains(clients, t.ClientID.UUID)
+	})
+
+	var subscriptions []uuid.UUID
+	s.subscriptions = slices.DeleteFunc(s.subscriptions, func(sub database.Subscription) bool {
+		if deleted(sub.UserID) {
+			subscriptions = append(subscriptions, sub.ID)
+			return true
+		}
+		return false
+	})
+	s.subscriptionEvents = slices.DeleteFunc(s.subscriptionEvents, func(e database.SubscriptionEvent) bool {
+		return slices.Contains(subscriptions, e.SubscriptionID)
+	})
+
+	var webhooks []uuid.UUID
+	s.webhookSubscriptions = slices.DeleteFunc(s.webhookSubscriptions, func(sub database.WebhookSubscription) bool {
+		if deleted(sub.UserID) {
+			webhooks = append(webhooks, sub.ID)
+			return true
+		}
+		return false
+	})
+	s.webhookDeliveries = slices.DeleteFunc(s.webhookDeliveries, func(d database.WebhookDelivery) bool {
+		return slices.Contains(webhooks, d.SubscriptionID)
+	})
+
+	s.userBlocks = slices.DeleteFunc(s.userBlocks, func(b database.UserBlock) bool {
+		return deleted(b.BlockerID) || deleted(b.BlockedID)
+	})
+	s.userMutes = slices.DeleteFunc(s.userMutes, func(m database.UserMute) bool {
+		return deleted(m.MuterID) || deleted(m.MutedID)
+	})
+
+	var reports []uuid.UUID
+	s.reports = slices.DeleteFunc(s.reports, func(r database.Report) bool {
+		if deleted(r.ReporterID) || deleted(r.TargetUserID) {
+			reports = append(reports, r.ID)
+			return true
+		}
+		return false
+	})
+	s.moderationActions = slices.DeleteFunc(s.moderationActions, func(a database.ModerationAction) bool {
+		return deleted(a.TargetUserID) || a.ReportID.Valid && slices.Contains(reports, a.ReportID.UUID)
+	})
+	for i, r := range s.reports {
+		if r.ClaimedBy.Valid && deleted(r.ClaimedBy.UUID) {
+			s.reports[i].ClaimedBy = uuid.NullUUID{}
+		}
+	}
+	for i, a := range s.moderationActions {
+		if a.ModeratorID.Valid && deleted(a.ModeratorID.UUID) {
+			s.moderationActions[i].ModeratorID = uuid.NullUUID{}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestUsersAreUniqueByEmail(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	_, err := s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "y"})
+	if !errors.Is(err, ErrUniqueViolation) {
+		t.Errorf("duplicate CreateUser error = %v, want ErrUniqueViolation", err)
+	}
+	_, err = s.GetUser(ctx, "b@example.com")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUser of a missing email error = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestForeignKeysAndCascade(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	_, err := s.CreateChirp(ctx, database.CreateChirpParams{Body: "orphan", UserID: uuid.New()})
+	if !errors.Is(err, ErrForeignKeyViolation) {
+		t.Errorf("CreateChirp for a missing user error = %v, want ErrForeignKeyViolation", err)
+	}
+
+	user, err := s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := s.CreateChirp(ctx, database.CreateChirpParams{Body: "first", UserID: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.CreateChirp(ctx, database.CreateChirpParams{Body: "second", UserID: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{Token: "t", UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chirps, err := s.GetChirps(ctx, uuid.NullUUID{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chirps) != 2 || chirps[0].ID != first.ID {
+		t.Errorf("GetChirps = %+v, want both chirps oldest first", chirps)
+	}
+
+	err = s.DeleteUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chirps, _ = s.GetChirps(ctx, uuid.NullUUID{})
+	if len(chirps) != 0 {
+		t.Errorf("GetChirps after DeleteUsers = %+v, want none", chirps)
+	}
+	_, err = s.GetUserFromRefreshToken(ctx, "t")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUserFromRefreshToken after DeleteUsers error = %v, want sql.ErrNoRows", err)
+	}
+}
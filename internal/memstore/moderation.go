@@ -0,0 +1,152 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) ClaimReport(ctx context.Context, arg database.ClaimReportParams) (database.Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.reports, func(r database.Report) bool { return r.ID == arg.ID && r.Status == "open" })
+	if i < 0 {
+		return database.Report{}, sql.ErrNoRows
+	}
+	now := time.Now()
+	s.reports[i].Status = "claimed"
+	s.reports[i].ClaimedBy = arg.ClaimedBy
+	s.reports[i].ClaimedAt = sql.NullTime{Time: now, Valid: true}
+	s.reports[i].UpdatedAt = now
+	return s.reports[i], nil
+}
+
+func (s *Store) CreateModerationAction(ctx context.Context, arg database.CreateModerationActionParams) (database.ModerationAction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.userExists(arg.TargetUserID) {
+		return database.ModerationAction{}, ErrForeignKeyViolation
+	}
+	action := database.ModerationAction{
+		ID:           uuid.New(),
+		CreatedAt:    time.Now(),
+		ReportID:     arg.ReportID,
+		ModeratorID:  arg.ModeratorID,
+		Action:       arg.Action,
+		TargetUserID: arg.TargetUserID,
+		ChirpID:      arg.ChirpID,
+		Note:         arg.Note,
+	}
+	s.moderationActions = append(s.moderationActions, action)
+	return action, nil
+}
+
+func (s *Store) CreateReport(ctx context.Context, arg database.CreateReportParams) (database.Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.userExists(arg.ReporterID) || !s.userExists(arg.TargetUserID) {
+		return database.Report{}, ErrForeignKeyViolation
+	}
+	now := time.Now()
+	report := database.Report{
+		ID:           uuid.New(),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		ReporterID:   arg.ReporterID,
+		TargetUserID: arg.TargetUserID,
+		ChirpID:      arg.ChirpID,
+		ChirpBody:    arg.ChirpBody,
+		Reason:       arg.Reason,
+		Details:      arg.Details,
+		Status:       "open",
+	}
+	s.reports = append(s.reports, report)
+	return report, nil
+}
+
+func (s *Store) GetModerationActionsForReport(ctx context.Context, reportID uuid.NullUUID) ([]database.ModerationAction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return filter(s.moderationActions, func(a database.ModerationAction) bool {
+		return reportID.Valid && a.ReportID == reportID
+	}), nil
+}
+
+func (s *Store) GetReport(ctx context.Context, id uuid.UUID) (database.Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.reports, func(r database.Report) bool { return r.ID == id })
+	if i < 0 {
+		return database.Report{}, sql.ErrNoRows
+	}
+	return s.reports[i], nil
+}
+
+func (s *Store) ListReports(ctx context.Context, arg database.ListReportsParams) ([]database.Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := filter(s.reports, func(r database.Report) bool { return r.Status == arg.Status })
+	slices.SortStableFunc(items, func(a, b database.Report) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	start := min(int(arg.Offset), len(items))
+	end := min(start+int(arg.Limit), len(items))
+	if start == end {
+		return nil, nil
+	}
+	return items[start:end], nil
+}
+
+func (s *Store) ResolveReport(ctx context.Context, arg database.ResolveReportParams) (database.Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.reports, func(r database.Report) bool {
+		return r.ID == arg.ID && r.Status == "claimed" && r.ClaimedBy.Valid && r.ClaimedBy == arg.ClaimedBy
+	})
+	if i < 0 {
+		return database.Report{}, sql.ErrNoRows
+	}
+	now := time.Now()
+	s.reports[i].Status = "resolved"
+	s.reports[i].Resolution = arg.Resolution
+	s.reports[i].ResolvedAt = sql.NullTime{Time: now, Valid: true}
+	s.reports[i].UpdatedAt = now
+	return s.reports[i], nil
+}
+
+func (s *Store) UnclaimReport(ctx context.Context, arg database.UnclaimReportParams) (database.Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.reports, func(r database.Report) bool {
+		return r.ID == arg.ID && r.Status == "claimed" && r.ClaimedBy.Valid && r.ClaimedBy == arg.ClaimedBy
+	})
+	if i < 0 {
+		return database.Report{}, sql.ErrNoRows
+	}
+	s.reports[i].Status = "open"
+	s.reports[i].ClaimedBy = uuid.NullUUID{}
+	s.reports[i].ClaimedAt = sql.NullTime{}
+	s.reports[i].UpdatedAt = time.Now()
+	return s.reports[i], nil
+}
+
+// detachReports clears the chirp of reports about deleted chirps, following
+// the ON DELETE SET NULL clause of the schema.
+func (s *Store) detachReports(deleted func(uuid.UUID) bool) {
+	for i, r := range s.reports {
+		if r.ChirpID.Valid && deleted(r.ChirpID.UUID) {
+			s.reports[i].ChirpID = uuid.NullUUID{}
+		}
+	}
+}
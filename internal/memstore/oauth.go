@@ -0,0 +1,81 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) ConsumeOAuthAuthorizationCode(ctx context.Context, codeHash string) (database.OauthAuthorizationCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.oauthAuthorizationCodes, func(c database.OauthAuthorizationCode) bool {
+		return c.CodeHash == codeHash && !c.UsedAt.Valid
+	})
+	if i < 0 {
+		return database.OauthAuthorizationCode{}, sql.ErrNoRows
+	}
+	s.oauthAuthorizationCodes[i].UsedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	return s.oauthAuthorizationCodes[i], nil
+}
+
+func (s *Store) CreateOAuthAuthorizationCode(ctx context.Context, arg database.CreateOAuthAuthorizationCodeParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.userExists(arg.UserID) || find(s.oauthClients, func(c database.OauthClient) bool { return c.ID == arg.ClientID }) < 0 {
+		return ErrForeignKeyViolation
+	}
+	if find(s.oauthAuthorizationCodes, func(c database.OauthAuthorizationCode) bool { return c.CodeHash == arg.CodeHash }) >= 0 {
+		return ErrUniqueViolation
+	}
+	s.oauthAuthorizationCodes = append(s.oauthAuthorizationCodes, database.OauthAuthorizationCode{
+		CodeHash:      arg.CodeHash,
+		CreatedAt:     time.Now(),
+		ClientID:      arg.ClientID,
+		UserID:        arg.UserID,
+		RedirectUri:   arg.RedirectUri,
+		Scopes:        slices.Clone(arg.Scopes),
+		CodeChallenge: arg.CodeChallenge,
+		ExpiresAt:     arg.ExpiresAt,
+	})
+	return nil
+}
+
+func (s *Store) CreateOAuthClient(ctx context.Context, arg database.CreateOAuthClientParams) (database.OauthClient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.userExists(arg.OwnerID) {
+		return database.OauthClient{}, ErrForeignKeyViolation
+	}
+	now := time.Now()
+	client := database.OauthClient{
+		ID:           uuid.New(),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		OwnerID:      arg.OwnerID,
+		Name:         arg.Name,
+		SecretHash:   arg.SecretHash,
+		RedirectUris: slices.Clone(arg.RedirectUris),
+		Scopes:       slices.Clone(arg.Scopes),
+	}
+	s.oauthClients = append(s.oauthClients, client)
+	return client, nil
+}
+
+func (s *Store) GetOAuthClient(ctx context.Context, id uuid.UUID) (database.OauthClient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.oauthClients, func(c database.OauthClient) bool { return c.ID == id })
+	if i < 0 {
+		return database.OauthClient{}, sql.ErrNoRows
+	}
+	return s.oauthClients[i], nil
+}
@@ -0,0 +1,182 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) ClaimDueWebhookDeliveries(ctx context.Context, limit int32) ([]database.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var due []int
+	for i, d := range s.webhookDeliveries {
+		if d.Status == "pending" && !d.NextAttemptAt.After(now) {
+			due = append(due, i)
+		}
+	}
+	slices.SortStableFunc(due, func(a, b int) int {
+		return s.webhookDeliveries[a].NextAttemptAt.Compare(s.webhookDeliveries[b].NextAttemptAt)
+	})
+	if len(due) > int(limit) {
+		due = due[:limit]
+	}
+
+	var items []database.WebhookDelivery
+	for _, i := range due {
+		d := &s.webhookDeliveries[i]
+		d.NextAttemptAt = now.Add(5 * time.Minute)
+		d.UpdatedAt = now
+		items = append(items, *d)
+	}
+	return items, nil
+}
+
+func (s *Store) CreateWebhookSubscription(ctx context.Context, arg database.CreateWebhookSubscriptionParams) (database.WebhookSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.userExists(arg.UserID) {
+		return database.WebhookSubscription{}, ErrForeignKeyViolation
+	}
+	now := time.Now()
+	sub := database.WebhookSubscription{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    arg.UserID,
+		Url:       arg.Url,
+		Secret:    arg.Secret,
+		Events:    slices.Clone(arg.Events),
+	}
+	s.webhookSubscriptions = append(s.webhookSubscriptions, sub)
+	return sub, nil
+}
+
+func (s *Store) DeleteWebhookSubscription(ctx context.Context, arg database.DeleteWebhookSubscriptionParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before := len(s.webhookSubscriptions)
+	s.webhookSubscriptions = slices.DeleteFunc(s.webhookSubscriptions, func(sub database.WebhookSubscription) bool {
+		return sub.ID == arg.ID && sub.UserID == arg.UserID
+	})
+	deleted := int64(before - len(s.webhookSubscriptions))
+	if deleted > 0 {
+		s.webhookDeliveries = slices.DeleteFunc(s.webhookDeliveries, func(d database.WebhookDelivery) bool {
+			return d.SubscriptionID == arg.ID
+		})
+	}
+	return deleted, nil
+}
+
+func (s *Store) EnqueueWebhookDeliveries(ctx context.Context, arg database.EnqueueWebhookDeliveriesParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, sub := range s.webhookSubscriptions {
+		if sub.UserID != arg.UserID || !slices.Contains(sub.Events, arg.Event) {
+			continue
+		}
+		s.webhookDeliveries = append(s.webhookDeliveries, database.WebhookDelivery{
+			ID:             uuid.New(),
+			CreatedAt:      now,
+			UpdatedAt:      now,
+			SubscriptionID: sub.ID,
+			Event:          arg.Event,
+			Payload:        slices.Clone(arg.Payload),
+			Status:         "pending",
+			NextAttemptAt:  now,
+		})
+	}
+	return nil
+}
+
+func (s *Store) GetWebhookDeliveries(ctx context.Context, subscriptionID uuid.UUID) ([]database.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := filter(s.webhookDeliveries, func(d database.WebhookDelivery) bool {
+		return d.SubscriptionID == subscriptionID
+	})
+	slices.Reverse(items)
+	slices.SortStableFunc(items, func(a, b database.WebhookDelivery) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	if len(items) > 100 {
+		items = items[:100]
+	}
+	return items, nil
+}
+
+func (s *Store) GetWebhookDelivery(ctx context.Context, id uuid.UUID) (database.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.webhookDeliveries, func(d database.WebhookDelivery) bool { return d.ID == id })
+	if i < 0 {
+		return database.WebhookDelivery{}, sql.ErrNoRows
+	}
+	return s.webhookDeliveries[i], nil
+}
+
+func (s *Store) GetWebhookSubscription(ctx context.Context, id uuid.UUID) (database.WebhookSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.webhookSubscriptions, func(sub database.WebhookSubscription) bool { return sub.ID == id })
+	if i < 0 {
+		return database.WebhookSubscription{}, sql.ErrNoRows
+	}
+	return s.webhookSubscriptions[i], nil
+}
+
+func (s *Store) GetWebhookSubscriptionsForUser(ctx context.Context, userID uuid.UUID) ([]database.WebhookSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return filter(s.webhookSubscriptions, func(sub database.WebhookSubscription) bool {
+		return sub.UserID == userID
+	}), nil
+}
+
+func (s *Store) RecordWebhookDeliveryAttempt(ctx context.Context, arg database.RecordWebhookDeliveryAttemptParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.webhookDeliveries, func(d database.WebhookDelivery) bool { return d.ID == arg.ID })
+	if i < 0 {
+		return nil
+	}
+	d := &s.webhookDeliveries[i]
+	d.Attempts++
+	d.Status = arg.Status
+	d.NextAttemptAt = arg.NextAttemptAt
+	d.LastStatusCode = arg.LastStatusCode
+	d.LastError = arg.LastError
+	d.DeliveredAt = arg.DeliveredAt
+	d.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *Store) RedeliverWebhookDelivery(ctx context.Context, id uuid.UUID) (database.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.webhookDeliveries, func(d database.WebhookDelivery) bool { return d.ID == id })
+	if i < 0 {
+		return database.WebhookDelivery{}, sql.ErrNoRows
+	}
+	now := time.Now()
+	d := &s.webhookDeliveries[i]
+	d.Status = "pending"
+	d.Attempts = 0
+	d.NextAttemptAt = now
+	d.UpdatedAt = now
+	return *d, nil
+}
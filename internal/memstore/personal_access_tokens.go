@@ -0,0 +1,100 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreatePersonalAccessToken(ctx context.Context, arg database.CreatePersonalAccessTokenParams) (database.PersonalAccessToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.userExists(arg.UserID) {
+		return database.PersonalAccessToken{}, ErrForeignKeyViolation
+	}
+	if find(s.personalAccessTokens, func(t database.PersonalAccessToken) bool { return t.TokenHash == arg.TokenHash }) >= 0 {
+		return database.PersonalAccessToken{}, ErrUniqueViolation
+	}
+	now := time.Now()
+	token := database.PersonalAccessToken{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    arg.UserID,
+		Name:      arg.Name,
+		TokenHash: arg.TokenHash,
+		Scopes:    slices.Clone(arg.Scopes),
+		ExpiresAt: arg.ExpiresAt,
+	}
+	s.personalAccessTokens = append(s.personalAccessTokens, token)
+	return token, nil
+}
+
+func (s *Store) GetPersonalAccessTokenByHash(ctx context.Context, tokenHash string) (database.PersonalAccessToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.personalAccessTokens, func(t database.PersonalAccessToken) bool { return t.TokenHash == tokenHash })
+	if i < 0 {
+		return database.PersonalAccessToken{}, sql.ErrNoRows
+	}
+	return s.personalAccessTokens[i], nil
+}
+
+func (s *Store) GetPersonalAccessTokensForUser(ctx context.Context, userID uuid.UUID) ([]database.PersonalAccessToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return filter(s.personalAccessTokens, func(t database.PersonalAccessToken) bool {
+		return t.UserID == userID && !t.RevokedAt.Valid
+	}), nil
+}
+
+func (s *Store) RevokePersonalAccessToken(ctx context.Context, arg database.RevokePersonalAccessTokenParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.revokePersonalAccessTokens(func(t database.PersonalAccessToken) bool {
+		return t.ID == arg.ID && t.UserID == arg.UserID
+	}), nil
+}
+
+func (s *Store) RevokePersonalAccessTokensForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.revokePersonalAccessTokens(func(t database.PersonalAccessToken) bool {
+		return t.UserID == userID
+	}), nil
+}
+
+func (s *Store) revokePersonalAccessTokens(match func(database.PersonalAccessToken) bool) int64 {
+	now := time.Now()
+	var count int64
+	for i := range s.personalAccessTokens {
+		t := &s.personalAccessTokens[i]
+		if match(*t) && !t.RevokedAt.Valid {
+			t.RevokedAt = sql.NullTime{Time: now, Valid: true}
+			t.UpdatedAt = now
+			count++
+		}
+	}
+	return count
+}
+
+func (s *Store) TouchPersonalAccessToken(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.personalAccessTokens {
+		if s.personalAccessTokens[i].ID == id {
+			s.personalAccessTokens[i].LastUsedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) createRefreshToken(token database.RefreshToken) (database.RefreshToken, error) {
+	if !s.userExists(token.UserID) {
+		return database.RefreshToken{}, ErrForeignKeyViolation
+	}
+	if find(s.refreshTokens, func(t database.RefreshToken) bool { return t.Token == token.Token }) >= 0 {
+		return database.RefreshToken{}, ErrUniqueViolation
+	}
+	now := time.Now()
+	token.CreatedAt = now
+	token.UpdatedAt = now
+	s.refreshTokens = append(s.refreshTokens, token)
+	return token, nil
+}
+
+func (s *Store) CreateOAuthRefreshToken(ctx context.Context, arg database.CreateOAuthRefreshTokenParams) (database.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.createRefreshToken(database.RefreshToken{
+		Token:     arg.Token,
+		UserID:    arg.UserID,
+		ExpiresAt: arg.ExpiresAt,
+		ClientID:  arg.ClientID,
+		Scopes:    slices.Clone(arg.Scopes),
+	})
+}
+
+func (s *Store) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.createRefreshToken(database.RefreshToken{
+		Token:     arg.Token,
+		UserID:    arg.UserID,
+		ExpiresAt: arg.ExpiresAt,
+	})
+}
+
+func (s *Store) GetRefreshToken(ctx context.Context, token string) (database.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.refreshTokens, func(t database.RefreshToken) bool { return t.Token == token })
+	if i < 0 {
+		return database.RefreshToken{}, sql.ErrNoRows
+	}
+	return s.refreshTokens[i], nil
+}
+
+func (s *Store) GetRefreshTokensForUser(ctx context.Context, userID uuid.UUID) ([]database.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return filter(s.refreshTokens, func(t database.RefreshToken) bool { return t.UserID == userID }), nil
+}
+
+func (s *Store) GetUserFromRefreshToken(ctx context.Context, token string) (database.GetUserFromRefreshTokenRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.refreshTokens, func(t database.RefreshToken) bool { return t.Token == token })
+	if i < 0 {
+		return database.GetUserFromRefreshTokenRow{}, sql.ErrNoRows
+	}
+	t := s.refreshTokens[i]
+	return database.GetUserFromRefreshTokenRow{
+		UserID:    t.UserID,
+		ExpiresAt: t.ExpiresAt,
+		RevokedAt: t.RevokedAt,
+		ClientID:  t.ClientID,
+	}, nil
+}
+
+func (s *Store) RevokeRefreshToken(ctx context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for i := range s.refreshTokens {
+		if s.refreshTokens[i].Token == token {
+			s.refreshTokens[i].RevokedAt = sql.NullTime{Time: now, Valid: true}
+			s.refreshTokens[i].UpdatedAt = now
+		}
+	}
+	return nil
+}
+
+func (s *Store) RevokeRefreshTokensForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var count int64
+	for i := range s.refreshTokens {
+		if s.refreshTokens[i].UserID == userID && !s.refreshTokens[i].RevokedAt.Valid {
+			s.refreshTokens[i].RevokedAt = sql.NullTime{Time: now, Valid: true}
+			s.refreshTokens[i].UpdatedAt = now
+			count++
+		}
+	}
+	return count, nil
+}
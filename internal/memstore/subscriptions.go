@@ -0,0 +1,107 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateSubscriptionEvent(ctx context.Context, arg database.CreateSubscriptionEventParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if find(s.subscriptions, func(sub database.Subscription) bool { return sub.ID == arg.SubscriptionID }) < 0 {
+		return ErrForeignKeyViolation
+	}
+	s.subscriptionEvents = append(s.subscriptionEvents, database.SubscriptionEvent{
+		ID:               uuid.New(),
+		CreatedAt:        time.Now(),
+		SubscriptionID:   arg.SubscriptionID,
+		Event:            arg.Event,
+		Status:           arg.Status,
+		CurrentPeriodEnd: arg.CurrentPeriodEnd,
+	})
+	return nil
+}
+
+func (s *Store) ExpireLapsedSubscriptions(ctx context.Context) ([]database.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var items []database.Subscription
+	for i := range s.subscriptions {
+		sub := &s.subscriptions[i]
+		if (sub.Status == "active" || sub.Status == "cancelled") && sub.CurrentPeriodEnd.Before(now) {
+			sub.Status = "expired"
+			sub.UpdatedAt = now
+			items = append(items, *sub)
+		}
+	}
+	return items, nil
+}
+
+func (s *Store) GetSubscriptionByUser(ctx context.Context, userID uuid.UUID) (database.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.subscriptions, func(sub database.Subscription) bool { return sub.UserID == userID })
+	if i < 0 {
+		return database.Subscription{}, sql.ErrNoRows
+	}
+	return s.subscriptions[i], nil
+}
+
+func (s *Store) GetSubscriptionEvents(ctx context.Context, subscriptionID uuid.UUID) ([]database.SubscriptionEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return filter(s.subscriptionEvents, func(e database.SubscriptionEvent) bool {
+		return e.SubscriptionID == subscriptionID
+	}), nil
+}
+
+func (s *Store) RevokeExpiredChirpyRed(ctx context.Context, id uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if find(s.subscriptions, func(sub database.Subscription) bool { return sub.UserID == id && sub.Status == "expired" }) < 0 {
+		return 0, nil
+	}
+	_, err := s.updateUser(id, func(u *database.User) {
+		u.IsChirpyRed = false
+		u.UpdatedAt = time.Now()
+	})
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return 1, err
+}
+
+func (s *Store) UpsertSubscription(ctx context.Context, arg database.UpsertSubscriptionParams) (database.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.userExists(arg.UserID) {
+		return database.Subscription{}, ErrForeignKeyViolation
+	}
+	now := time.Now()
+	i := find(s.subscriptions, func(sub database.Subscription) bool { return sub.UserID == arg.UserID })
+	if i < 0 {
+		s.subscriptions = append(s.subscriptions, database.Subscription{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			UserID:    arg.UserID,
+		})
+		i = len(s.subscriptions) - 1
+	}
+	sub := &s.subscriptions[i]
+	sub.UpdatedAt = now
+	sub.Plan = arg.Plan
+	sub.Status = arg.Status
+	sub.CurrentPeriodEnd = arg.CurrentPeriodEnd
+	return *sub, nil
+}
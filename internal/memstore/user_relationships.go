@@ -0,0 +1,112 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) BlockUser(ctx context.Context, arg database.BlockUserParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.userExists(arg.BlockerID) || !s.userExists(arg.BlockedID) {
+		return ErrForeignKeyViolation
+	}
+	if s.blocked(arg.BlockerID, arg.BlockedID) {
+		return nil
+	}
+	s.userBlocks = append(s.userBlocks, database.UserBlock{
+		BlockerID: arg.BlockerID,
+		BlockedID: arg.BlockedID,
+		CreatedAt: time.Now(),
+	})
+	return nil
+}
+
+// blocked reports whether blocker has blocked blocked.
+func (s *Store) blocked(blocker, blocked uuid.UUID) bool {
+	return find(s.userBlocks, func(b database.UserBlock) bool {
+		return b.BlockerID == blocker && b.BlockedID == blocked
+	}) >= 0
+}
+
+// muted reports whether muter has muted muted.
+func (s *Store) muted(muter, muted uuid.UUID) bool {
+	return find(s.userMutes, func(m database.UserMute) bool {
+		return m.MuterID == muter && m.MutedID == muted
+	}) >= 0
+}
+
+func (s *Store) CountBlocksBetween(ctx context.Context, arg database.CountBlocksBetweenParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	if s.blocked(arg.UserID, arg.OtherUserID) {
+		count++
+	}
+	if s.blocked(arg.OtherUserID, arg.UserID) {
+		count++
+	}
+	return count, nil
+}
+
+func (s *Store) GetBlocksForUser(ctx context.Context, blockerID uuid.UUID) ([]database.UserBlock, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := filter(s.userBlocks, func(b database.UserBlock) bool { return b.BlockerID == blockerID })
+	slices.SortStableFunc(items, func(a, b database.UserBlock) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return items, nil
+}
+
+func (s *Store) GetMutesForUser(ctx context.Context, muterID uuid.UUID) ([]database.UserMute, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := filter(s.userMutes, func(m database.UserMute) bool { return m.MuterID == muterID })
+	slices.SortStableFunc(items, func(a, b database.UserMute) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return items, nil
+}
+
+func (s *Store) MuteUser(ctx context.Context, arg database.MuteUserParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.userExists(arg.MuterID) || !s.userExists(arg.MutedID) {
+		return ErrForeignKeyViolation
+	}
+	if s.muted(arg.MuterID, arg.MutedID) {
+		return nil
+	}
+	s.userMutes = append(s.userMutes, database.UserMute{
+		MuterID:   arg.MuterID,
+		MutedID:   arg.MutedID,
+		CreatedAt: time.Now(),
+	})
+	return nil
+}
+
+func (s *Store) UnblockUser(ctx context.Context, arg database.UnblockUserParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.userBlocks = slices.DeleteFunc(s.userBlocks, func(b database.UserBlock) bool {
+		return b.BlockerID == arg.BlockerID && b.BlockedID == arg.BlockedID
+	})
+	return nil
+}
+
+func (s *Store) UnmuteUser(ctx context.Context, arg database.UnmuteUserParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.userMutes = slices.DeleteFunc(s.userMutes, func(m database.UserMute) bool {
+		return m.MuterID == arg.MuterID && m.MutedID == arg.MutedID
+	})
+	return nil
+}
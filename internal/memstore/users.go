@@ -0,0 +1,212 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if find(s.users, func(u database.User) bool { return u.Email == arg.Email }) >= 0 {
+		return database.User{}, ErrUniqueViolation
+	}
+	now := time.Now()
+	user := database.User{
+		ID:             uuid.New(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Email:          arg.Email,
+		HashedPassword: arg.HashedPassword,
+		Role:           "user",
+	}
+	s.users = append(s.users, user)
+	return user, nil
+}
+
+func (s *Store) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users = slices.DeleteFunc(s.users, func(u database.User) bool { return u.ID == id })
+	s.deleteUserData(func(userID uuid.UUID) bool { return userID == id })
+	return nil
+}
+
+func (s *Store) DeleteUserIfDue(ctx context.Context, id uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	i := find(s.users, func(u database.User) bool {
+		return u.ID == id && u.DeletionScheduledAt.Valid && !u.DeletionScheduledAt.Time.After(now)
+	})
+	if i < 0 {
+		return 0, nil
+	}
+	s.users = slices.Delete(s.users, i, i+1)
+	s.deleteUserData(func(userID uuid.UUID) bool { return userID == id })
+	return 1, nil
+}
+
+func (s *Store) DeleteUsers(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users = nil
+	s.deleteUserData(func(uuid.UUID) bool { return true })
+	return nil
+}
+
+func (s *Store) GetUser(ctx context.Context, email string) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.users, func(u database.User) bool { return u.Email == email })
+	if i < 0 {
+		return database.User{}, sql.ErrNoRows
+	}
+	return s.users[i], nil
+}
+
+func (s *Store) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := find(s.users, func(u database.User) bool { return u.ID == id })
+	if i < 0 {
+		return database.User{}, sql.ErrNoRows
+	}
+	return s.users[i], nil
+}
+
+func (s *Store) GetUserPasswordHashes(ctx context.Context) ([]database.GetUserPasswordHashesRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []database.GetUserPasswordHashesRow
+	for _, u := range s.users {
+		items = append(items, database.GetUserPasswordHashesRow{ID: u.ID, HashedPassword: u.HashedPassword})
+	}
+	return items, nil
+}
+
+func (s *Store) GetUsersDueForDeletion(ctx context.Context) ([]database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	items := filter(s.users, func(u database.User) bool {
+		return u.DeletionScheduledAt.Valid && !u.DeletionScheduledAt.Time.After(now)
+	})
+	slices.SortStableFunc(items, func(a, b database.User) int {
+		return a.DeletionScheduledAt.Time.Compare(b.DeletionScheduledAt.Time)
+	})
+	return items, nil
+}
+
+// updateUser applies update to the user with the given ID.
+func (s *Store) updateUser(id uuid.UUID, update func(*database.User)) (database.User, error) {
+	i := find(s.users, func(u database.User) bool { return u.ID == id })
+	if i < 0 {
+		return database.User{}, sql.ErrNoRows
+	}
+	update(&s.users[i])
+	return s.users[i], nil
+}
+
+func (s *Store) ScheduleUserDeletion(ctx context.Context, arg database.ScheduleUserDeletionParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.updateUser(arg.ID, func(u *database.User) {
+		u.DeletionScheduledAt = arg.DeletionScheduledAt
+		u.UpdatedAt = time.Now()
+	})
+}
+
+func (s *Store) SetUserChirpyRed(ctx context.Context, id uuid.UUID) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.updateUser(id, func(u *database.User) {
+		u.IsChirpyRed = true
+	})
+}
+
+func (s *Store) SetUserChirpyRedStatus(ctx context.Context, arg database.SetUserChirpyRedStatusParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.updateUser(arg.ID, func(u *database.User) {
+		u.IsChirpyRed = arg.IsChirpyRed
+		u.UpdatedAt = time.Now()
+	})
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	return err
+}
+
+func (s *Store) SetUserEmailPassword(ctx context.Context, arg database.SetUserEmailPasswordParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if find(s.users, func(u database.User) bool { return u.Email == arg.Email && u.ID != arg.ID }) >= 0 {
+		return database.User{}, ErrUniqueViolation
+	}
+	return s.updateUser(arg.ID, func(u *database.User) {
+		u.Email = arg.Email
+		u.HashedPassword = arg.HashedPassword
+	})
+}
+
+func (s *Store) SetUserPassword(ctx context.Context, arg database.SetUserPasswordParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.updateUser(arg.ID, func(u *database.User) {
+		u.HashedPassword = arg.HashedPassword
+		u.UpdatedAt = time.Now()
+	})
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	return err
+}
+
+func (s *Store) SetUserRole(ctx context.Context, arg database.SetUserRoleParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.updateUser(arg.ID, func(u *database.User) {
+		u.Role = arg.Role
+		u.UpdatedAt = time.Now()
+	})
+}
+
+func (s *Store) SetUserShadowbanned(ctx context.Context, arg database.SetUserShadowbannedParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.updateUser(arg.ID, func(u *database.User) {
+		u.Shadowbanned = arg.Shadowbanned
+		u.UpdatedAt = time.Now()
+	})
+}
+
+func (s *Store) SuspendUser(ctx context.Context, arg database.SuspendUserParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.updateUser(arg.ID, func(u *database.User) {
+		u.SuspendedUntil = arg.SuspendedUntil
+		u.UpdatedAt = time.Now()
+	})
+}
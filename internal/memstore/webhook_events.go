@@ -0,0 +1,33 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+)
+
+func (s *Store) ClaimWebhookEvent(ctx context.Context, arg database.ClaimWebhookEventParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if find(s.webhookEvents, func(e database.WebhookEvent) bool { return e.EventID == arg.EventID }) >= 0 {
+		return 0, nil
+	}
+	s.webhookEvents = append(s.webhookEvents, database.WebhookEvent{
+		EventID:    arg.EventID,
+		Source:     arg.Source,
+		Event:      arg.Event,
+		ReceivedAt: time.Now(),
+	})
+	return 1, nil
+}
+
+func (s *Store) ReleaseWebhookEvent(ctx context.Context, eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.webhookEvents = slices.DeleteFunc(s.webhookEvents, func(e database.WebhookEvent) bool { return e.EventID == eventID })
+	return nil
+}
@@ -0,0 +1,26 @@
+package sqlitestore
+
+import (
+	"context"
+
+	"github.com/arglp/chirpy/internal/database"
+	sqlitedb "github.com/arglp/chirpy/internal/database/sqlite"
+)
+
+func (s *Store) CreateAuditEvent(ctx context.Context, arg database.CreateAuditEventParams) error {
+	return s.q.CreateAuditEvent(ctx, sqlitedb.CreateAuditEventParams(arg))
+}
+
+func (s *Store) ListAuditEvents(ctx context.Context, arg database.ListAuditEventsParams) ([]database.AuditEvent, error) {
+	events, err := s.q.ListAuditEvents(ctx, sqlitedb.ListAuditEventsParams{
+		ActorID:  arg.ActorID,
+		TargetID: arg.TargetID,
+		Since:    arg.Since,
+		Until:    arg.Until,
+		Limit:    int64(arg.Limit),
+		Offset:   int64(arg.Offset),
+	})
+	return convertAll(events, func(e sqlitedb.AuditEvent) database.AuditEvent {
+		return database.AuditEvent(e)
+	}), err
+}
@@ -0,0 +1,54 @@
+package sqlitestore
+
+import (
+	"context"
+
+	"github.com/arglp/chirpy/internal/database"
+	sqlitedb "github.com/arglp/chirpy/internal/database/sqlite"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CountChirpsByUserSince(ctx context.Context, arg database.CountChirpsByUserSinceParams) (int64, error) {
+	return s.q.CountChirpsByUserSince(ctx, sqlitedb.CountChirpsByUserSinceParams(arg))
+}
+
+func (s *Store) CreateChirp(ctx context.Context, arg database.CreateChirpParams) (database.Chirp, error) {
+	chirp, err := s.q.CreateChirp(ctx, sqlitedb.CreateChirpParams(arg))
+	return database.Chirp(chirp), err
+}
+
+func (s *Store) DeleteChirpByID(ctx context.Context, id uuid.UUID) error {
+	return s.q.DeleteChirpByID(ctx, id)
+}
+
+func (s *Store) DeleteChirps(ctx context.Context) error {
+	return s.q.DeleteChirps(ctx)
+}
+
+func (s *Store) GetChirp(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	chirp, err := s.q.GetChirp(ctx, id)
+	return database.Chirp(chirp), err
+}
+
+func (s *Store) GetChirps(ctx context.Context, viewerID uuid.NullUUID) ([]database.Chirp, error) {
+	chirps, err := s.q.GetChirps(ctx, viewerID)
+	return convertAll(chirps, toChirp), err
+}
+
+func (s *Store) GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]database.Chirp, error) {
+	chirps, err := s.q.GetChirpsByUser(ctx, userID)
+	return convertAll(chirps, toChirp), err
+}
+
+func (s *Store) HideChirp(ctx context.Context, id uuid.UUID) error {
+	return s.q.HideChirp(ctx, id)
+}
+
+func (s *Store) UpdateChirpBody(ctx context.Context, arg database.UpdateChirpBodyParams) (database.Chirp, error) {
+	chirp, err := s.q.UpdateChirpBody(ctx, sqlitedb.UpdateChirpBodyParams(arg))
+	return database.Chirp(chirp), err
+}
+
+func toChirp(c sqlitedb.Chirp) database.Chirp {
+	return database.Chirp(c)
+}
@@ -0,0 +1,34 @@
+package sqlitestore
+
+import (
+	"context"
+
+	"github.com/arglp/chirpy/internal/database"
+	sqlitedb "github.com/arglp/chirpy/internal/database/sqlite"
+	"github.com/google/uuid"
+)
+
+func (s *Store) ClaimPendingDataExports(ctx context.Context, limit int32) ([]database.DataExport, error) {
+	exports, err := s.q.ClaimPendingDataExports(ctx, int64(limit))
+	return convertAll(exports, func(e sqlitedb.DataExport) database.DataExport {
+		return database.DataExport(e)
+	}), err
+}
+
+func (s *Store) CreateDataExport(ctx context.Context, userID uuid.UUID) (database.DataExport, error) {
+	export, err := s.q.CreateDataExport(ctx, userID)
+	return database.DataExport(export), err
+}
+
+func (s *Store) DeleteExpiredDataExports(ctx context.Context) (int64, error) {
+	return s.q.DeleteExpiredDataExports(ctx)
+}
+
+func (s *Store) FinishDataExport(ctx context.Context, arg database.FinishDataExportParams) error {
+	return s.q.FinishDataExport(ctx, sqlitedb.FinishDataExportParams(arg))
+}
+
+func (s *Store) GetLatestDataExportForUser(ctx context.Context, userID uuid.UUID) (database.DataExport, error) {
+	export, err := s.q.GetLatestDataExportForUser(ctx, userID)
+	return database.DataExport(export), err
+}
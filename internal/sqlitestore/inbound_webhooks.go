@@ -0,0 +1,35 @@
+package sqlitestore
+
+import (
+	"context"
+
+	"github.com/arglp/chirpy/internal/database"
+	sqlitedb "github.com/arglp/chirpy/internal/database/sqlite"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateInboundWebhook(ctx context.Context, arg database.CreateInboundWebhookParams) (database.InboundWebhook, error) {
+	webhook, err := s.q.CreateInboundWebhook(ctx, sqlitedb.CreateInboundWebhookParams(arg))
+	return database.InboundWebhook(webhook), err
+}
+
+func (s *Store) FinishInboundWebhook(ctx context.Context, arg database.FinishInboundWebhookParams) (database.InboundWebhook, error) {
+	webhook, err := s.q.FinishInboundWebhook(ctx, sqlitedb.FinishInboundWebhookParams(arg))
+	return database.InboundWebhook(webhook), err
+}
+
+func (s *Store) GetInboundWebhook(ctx context.Context, id uuid.UUID) (database.InboundWebhook, error) {
+	webhook, err := s.q.GetInboundWebhook(ctx, id)
+	return database.InboundWebhook(webhook), err
+}
+
+func (s *Store) ListInboundWebhooks(ctx context.Context, arg database.ListInboundWebhooksParams) ([]database.InboundWebhook, error) {
+	webhooks, err := s.q.ListInboundWebhooks(ctx, sqlitedb.ListInboundWebhooksParams{
+		Outcome: arg.Outcome,
+		Limit:   int64(arg.Limit),
+		Offset:  int64(arg.Offset),
+	})
+	return convertAll(webhooks, func(w sqlitedb.InboundWebhook) database.InboundWebhook {
+		return database.InboundWebhook(w)
+	}), err
+}
@@ -0,0 +1,17 @@
+package sqlitestore
+
+import (
+	"context"
+
+	"github.com/arglp/chirpy/internal/database"
+	sqlitedb "github.com/arglp/chirpy/internal/database/sqlite"
+)
+
+func (s *Store) ConsumeMagicLinkToken(ctx context.Context, tokenHash string) (database.MagicLinkToken, error) {
+	token, err := s.q.ConsumeMagicLinkToken(ctx, tokenHash)
+	return database.MagicLinkToken(token), err
+}
+
+func (s *Store) CreateMagicLinkToken(ctx context.Context, arg database.CreateMagicLinkTokenParams) error {
+	return s.q.CreateMagicLinkToken(ctx, sqlitedb.CreateMagicLinkTokenParams(arg))
+}
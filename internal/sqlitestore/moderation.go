@@ -0,0 +1,59 @@
+package sqlitestore
+
+import (
+	"context"
+
+	"github.com/arglp/chirpy/internal/database"
+	sqlitedb "github.com/arglp/chirpy/internal/database/sqlite"
+	"github.com/google/uuid"
+)
+
+func (s *Store) ClaimReport(ctx context.Context, arg database.ClaimReportParams) (database.Report, error) {
+	report, err := s.q.ClaimReport(ctx, sqlitedb.ClaimReportParams(arg))
+	return database.Report(report), err
+}
+
+func (s *Store) CreateModerationAction(ctx context.Context, arg database.CreateModerationActionParams) (database.ModerationAction, error) {
+	action, err := s.q.CreateModerationAction(ctx, sqlitedb.CreateModerationActionParams(arg))
+	return database.ModerationAction(action), err
+}
+
+func (s *Store) CreateReport(ctx context.Context, arg database.CreateReportParams) (database.Report, error) {
+	report, err := s.q.CreateReport(ctx, sqlitedb.CreateReportParams(arg))
+	return database.Report(report), err
+}
+
+func (s *Store) GetModerationActionsForReport(ctx context.Context, reportID uuid.NullUUID) ([]database.ModerationAction, error) {
+	actions, err := s.q.GetModerationActionsForReport(ctx, reportID)
+	return convertAll(actions, func(a sqlitedb.ModerationAction) database.ModerationAction {
+		return database.ModerationAction(a)
+	}), err
+}
+
+func (s *Store) GetReport(ctx context.Context, id uuid.UUID) (database.Report, error) {
+	report, err := s.q.GetReport(ctx, id)
+	return database.Report(report), err
+}
+
+func (s *Store) ListReports(ctx context.Context, arg database.ListReportsParams) ([]database.Report, error) {
+	reports, err := s.q.ListReports(ctx, sqlitedb.ListReportsParams{
+		Status: arg.Status,
+		Limit:  int64(arg.Limit),
+		Offset: int64(arg.Offset),
+	})
+	return convertAll(reports, toReport), err
+}
+
+func (s *Store) ResolveReport(ctx context.Context, arg database.ResolveReportParams) (database.Report, error) {
+	report, err := s.q.ResolveReport(ctx, sqlitedb.ResolveReportParams(arg))
+	return database.Report(report), err
+}
+
+func (s *Store) UnclaimReport(ctx context.Context, arg database.UnclaimReportParams) (database.Report, error) {
+	report, err := s.q.UnclaimReport(ctx, sqlitedb.UnclaimReportParams(arg))
+	return database.Report(report), err
+}
+
+func toReport(r sqlitedb.Report) database.Report {
+	return database.Report(r)
+}
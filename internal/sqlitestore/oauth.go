@@ -0,0 +1,65 @@
+package sqlitestore
+
+import (
+	"context"
+
+	"github.com/arglp/chirpy/internal/database"
+	sqlitedb "github.com/arglp/chirpy/internal/database/sqlite"
+	"github.com/google/uuid"
+)
+
+func (s *Store) ConsumeOAuthAuthorizationCode(ctx context.Context, codeHash string) (database.OauthAuthorizationCode, error) {
+	c, err := s.q.ConsumeOAuthAuthorizationCode(ctx, codeHash)
+	return database.OauthAuthorizationCode{
+		CodeHash:      c.CodeHash,
+		CreatedAt:     c.CreatedAt,
+		ClientID:      c.ClientID,
+		UserID:        c.UserID,
+		RedirectUri:   c.RedirectUri,
+		Scopes:        c.Scopes,
+		CodeChallenge: c.CodeChallenge,
+		ExpiresAt:     c.ExpiresAt,
+		UsedAt:        c.UsedAt,
+	}, err
+}
+
+func (s *Store) CreateOAuthAuthorizationCode(ctx context.Context, arg database.CreateOAuthAuthorizationCodeParams) error {
+	return s.q.CreateOAuthAuthorizationCode(ctx, sqlitedb.CreateOAuthAuthorizationCodeParams{
+		CodeHash:      arg.CodeHash,
+		ClientID:      arg.ClientID,
+		UserID:        arg.UserID,
+		RedirectUri:   arg.RedirectUri,
+		Scopes:        arg.Scopes,
+		CodeChallenge: arg.CodeChallenge,
+		ExpiresAt:     arg.ExpiresAt,
+	})
+}
+
+func (s *Store) CreateOAuthClient(ctx context.Context, arg database.CreateOAuthClientParams) (database.OauthClient, error) {
+	client, err := s.q.CreateOAuthClient(ctx, sqlitedb.CreateOAuthClientParams{
+		OwnerID:      arg.OwnerID,
+		Name:         arg.Name,
+		SecretHash:   arg.SecretHash,
+		RedirectUris: arg.RedirectUris,
+		Scopes:       arg.Scopes,
+	})
+	return toOAuthClient(client), err
+}
+
+func (s *Store) GetOAuthClient(ctx context.Context, id uuid.UUID) (database.OauthClient, error) {
+	client, err := s.q.GetOAuthClient(ctx, id)
+	return toOAuthClient(client), err
+}
+
+func toOAuthClient(c sqlitedb.OauthClient) database.OauthClient {
+	return database.OauthClient{
+		ID:           c.ID,
+		CreatedAt:    c.CreatedAt,
+		UpdatedAt:    c.UpdatedAt,
+		OwnerID:      c.OwnerID,
+		Name:         c.Name,
+		SecretHash:   c.SecretHash,
+		RedirectUris: c.RedirectUris,
+		Scopes:       c.Scopes,
+	}
+}
@@ -0,0 +1,81 @@
+package sqlitestore
+
+import (
+	"context"
+
+	"github.com/arglp/chirpy/internal/database"
+	sqlitedb "github.com/arglp/chirpy/internal/database/sqlite"
+	"github.com/google/uuid"
+)
+
+func (s *Store) ClaimDueWebhookDeliveries(ctx context.Context, limit int32) ([]database.WebhookDelivery, error) {
+	deliveries, err := s.q.ClaimDueWebhookDeliveries(ctx, int64(limit))
+	return convertAll(deliveries, toWebhookDelivery), err
+}
+
+func (s *Store) CreateWebhookSubscription(ctx context.Context, arg database.CreateWebhookSubscriptionParams) (database.WebhookSubscription, error) {
+	subscription, err := s.q.CreateWebhookSubscription(ctx, sqlitedb.CreateWebhookSubscriptionParams{
+		UserID: arg.UserID,
+		Url:    arg.Url,
+		Secret: arg.Secret,
+		Events: arg.Events,
+	})
+	return toWebhookSubscription(subscription), err
+}
+
+func (s *Store) DeleteWebhookSubscription(ctx context.Context, arg database.DeleteWebhookSubscriptionParams) (int64, error) {
+	return s.q.DeleteWebhookSubscription(ctx, sqlitedb.DeleteWebhookSubscriptionParams(arg))
+}
+
+func (s *Store) EnqueueWebhookDeliveries(ctx context.Context, arg database.EnqueueWebhookDeliveriesParams) error {
+	return s.q.EnqueueWebhookDeliveries(ctx, sqlitedb.EnqueueWebhookDeliveriesParams{
+		Event:   arg.Event,
+		Payload: arg.Payload,
+		UserID:  arg.UserID,
+	})
+}
+
+func (s *Store) GetWebhookDeliveries(ctx context.Context, subscriptionID uuid.UUID) ([]database.WebhookDelivery, error) {
+	deliveries, err := s.q.GetWebhookDeliveries(ctx, subscriptionID)
+	return convertAll(deliveries, toWebhookDelivery), err
+}
+
+func (s *Store) GetWebhookDelivery(ctx context.Context, id uuid.UUID) (database.WebhookDelivery, error) {
+	delivery, err := s.q.GetWebhookDelivery(ctx, id)
+	return database.WebhookDelivery(delivery), err
+}
+
+func (s *Store) GetWebhookSubscription(ctx context.Context, id uuid.UUID) (database.WebhookSubscription, error) {
+	subscription, err := s.q.GetWebhookSubscription(ctx, id)
+	return toWebhookSubscription(subscription), err
+}
+
+func (s *Store) GetWebhookSubscriptionsForUser(ctx context.Context, userID uuid.UUID) ([]database.WebhookSubscription, error) {
+	subscriptions, err := s.q.GetWebhookSubscriptionsForUser(ctx, userID)
+	return convertAll(subscriptions, toWebhookSubscription), err
+}
+
+func (s *Store) RecordWebhookDeliveryAttempt(ctx context.Context, arg database.RecordWebhookDeliveryAttemptParams) error {
+	return s.q.RecordWebhookDeliveryAttempt(ctx, sqlitedb.RecordWebhookDeliveryAttemptParams(arg))
+}
+
+func (s *Store) RedeliverWebhookDelivery(ctx context.Context, id uuid.UUID) (database.WebhookDelivery, error) {
+	delivery, err := s.q.RedeliverWebhookDelivery(ctx, id)
+	return database.WebhookDelivery(delivery), err
+}
+
+func toWebhookDelivery(d sqlitedb.WebhookDelivery) database.WebhookDelivery {
+	return database.WebhookDelivery(d)
+}
+
+func toWebhookSubscription(s sqlitedb.WebhookSubscription) database.WebhookSubscription {
+	return database.WebhookSubscription{
+		ID:        s.ID,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+		UserID:    s.UserID,
+		Url:       s.Url,
+		Secret:    s.Secret,
+		Events:    s.Events,
+	}
+}
@@ -0,0 +1,57 @@
+package sqlitestore
+
+import (
+	"context"
+
+	"github.com/arglp/chirpy/internal/database"
+	sqlitedb "github.com/arglp/chirpy/internal/database/sqlite"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreatePersonalAccessToken(ctx context.Context, arg database.CreatePersonalAccessTokenParams) (database.PersonalAccessToken, error) {
+	token, err := s.q.CreatePersonalAccessToken(ctx, sqlitedb.CreatePersonalAccessTokenParams{
+		UserID:    arg.UserID,
+		Name:      arg.Name,
+		TokenHash: arg.TokenHash,
+		Scopes:    arg.Scopes,
+		ExpiresAt: arg.ExpiresAt,
+	})
+	return toPersonalAccessToken(token), err
+}
+
+func (s *Store) GetPersonalAccessTokenByHash(ctx context.Context, tokenHash string) (database.PersonalAccessToken, error) {
+	token, err := s.q.GetPersonalAccessTokenByHash(ctx, tokenHash)
+	return toPersonalAccessToken(token), err
+}
+
+func (s *Store) GetPersonalAccessTokensForUser(ctx context.Context, userID uuid.UUID) ([]database.PersonalAccessToken, error) {
+	tokens, err := s.q.GetPersonalAccessTokensForUser(ctx, userID)
+	return convertAll(tokens, toPersonalAccessToken), err
+}
+
+func (s *Store) RevokePersonalAccessToken(ctx context.Context, arg database.RevokePersonalAccessTokenParams) (int64, error) {
+	return s.q.RevokePersonalAccessToken(ctx, sqlitedb.RevokePersonalAccessTokenParams(arg))
+}
+
+func (s *Store) RevokePersonalAccessTokensForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return s.q.RevokePersonalAccessTokensForUser(ctx, userID)
+}
+
+func (s *Store) TouchPersonalAccessToken(ctx context.Context, id uuid.UUID) error {
+	return s.q.TouchPersonalAccessToken(ctx, id)
+}
+
+func toPersonalAccessToken(t sqlitedb.PersonalAccessToken) database.PersonalAccessToken {
+	return database.PersonalAccessToken{
+		ID:         t.ID,
+		CreatedAt:  t.CreatedAt,
+		UpdatedAt:  t.UpdatedAt,
+		UserID:     t.UserID,
+		Name:       t.Name,
+		TokenHash:  t.TokenHash,
+		Scopes:     t.Scopes,
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+		RevokedAt:  t.RevokedAt,
+	}
+}
@@ -0,0 +1,61 @@
+package sqlitestore
+
+import (
+	"context"
+
+	"github.com/arglp/chirpy/internal/database"
+	sqlitedb "github.com/arglp/chirpy/internal/database/sqlite"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateOAuthRefreshToken(ctx context.Context, arg database.CreateOAuthRefreshTokenParams) (database.RefreshToken, error) {
+	token, err := s.q.CreateOAuthRefreshToken(ctx, sqlitedb.CreateOAuthRefreshTokenParams{
+		Token:     arg.Token,
+		UserID:    arg.UserID,
+		ExpiresAt: arg.ExpiresAt,
+		ClientID:  arg.ClientID,
+		Scopes:    arg.Scopes,
+	})
+	return toRefreshToken(token), err
+}
+
+func (s *Store) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.RefreshToken, error) {
+	token, err := s.q.CreateRefreshToken(ctx, sqlitedb.CreateRefreshTokenParams(arg))
+	return toRefreshToken(token), err
+}
+
+func (s *Store) GetRefreshToken(ctx context.Context, token string) (database.RefreshToken, error) {
+	refreshToken, err := s.q.GetRefreshToken(ctx, token)
+	return toRefreshToken(refreshToken), err
+}
+
+func (s *Store) GetRefreshTokensForUser(ctx context.Context, userID uuid.UUID) ([]database.RefreshToken, error) {
+	refreshTokens, err := s.q.GetRefreshTokensForUser(ctx, userID)
+	return convertAll(refreshTokens, toRefreshToken), err
+}
+
+func (s *Store) GetUserFromRefreshToken(ctx context.Context, token string) (database.GetUserFromRefreshTokenRow, error) {
+	row, err := s.q.GetUserFromRefreshToken(ctx, token)
+	return database.GetUserFromRefreshTokenRow(row), err
+}
+
+func (s *Store) RevokeRefreshToken(ctx context.Context, token string) error {
+	return s.q.RevokeRefreshToken(ctx, token)
+}
+
+func (s *Store) RevokeRefreshTokensForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return s.q.RevokeRefreshTokensForUser(ctx, userID)
+}
+
+func toRefreshToken(t sqlitedb.RefreshToken) database.RefreshToken {
+	return database.RefreshToken{
+		Token:     t.Token,
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+		UserID:    t.UserID,
+		ExpiresAt: t.ExpiresAt,
+		RevokedAt: t.RevokedAt,
+		ClientID:  t.ClientID,
+		Scopes:    t.Scopes,
+	}
+}
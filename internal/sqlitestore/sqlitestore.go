@@ -0,0 +1,122 @@
+// Package sqlitestore runs Chirpy on SQLite. It adapts the queries generated
+// into internal/database/sqlite to database.Querier and registers the
+// gen_random_uuid() and NOW() functions those queries share with Postgres.
+//
+// Every time is written in UTC with the same layout NOW() uses, so
+// timestamps compare correctly as text.
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	sqlitedb "github.com/arglp/chirpy/internal/database/sqlite"
+	"github.com/google/uuid"
+	"modernc.org/sqlite"
+)
+
+// timeLayout matches the driver's _time_format=sqlite, which is how time
+// parameters are written.
+const timeLayout = "2006-01-02 15:04:05.999999999-07:00"
+
+func init() {
+	sqlite.MustRegisterScalarFunction("gen_random_uuid", 0, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		return uuid.NewString(), nil
+	})
+	sqlite.MustRegisterScalarFunction("now", 0, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		return time.Now().UTC().Format(timeLayout), nil
+	})
+}
+
+// Open opens the database named by a sqlite: URL, either relative
+// (sqlite:chirpy.db) or absolute (sqlite:///var/lib/chirpy/chirpy.db).
+// Foreign keys are switched on for every connection so ON DELETE CASCADE
+// behaves as it does on Postgres.
+func Open(dbURL string) (*sql.DB, error) {
+	u, err := url.Parse(dbURL)
+	if err != nil || u.Scheme != "sqlite" {
+		return nil, fmt.Errorf("sqlitestore: %q is not a sqlite: URL", dbURL)
+	}
+	path := u.Opaque
+	if path == "" {
+		path = u.Host + u.Path
+	}
+	if path == "" {
+		return nil, fmt.Errorf("sqlitestore: %q has no database path", dbURL)
+	}
+
+	query := u.Query()
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Add("_pragma", "busy_timeout(5000)")
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Set("_time_format", "sqlite")
+	return sql.Open("sqlite", "file:"+path+"?"+query.Encode())
+}
+
+// Store implements database.Querier on a SQLite database opened by Open.
+type Store struct {
+	q *sqlitedb.Queries
+}
+
+var _ database.Querier = (*Store)(nil)
+
+func New(db sqlitedb.DBTX) *Store {
+	return &Store{q: sqlitedb.New(utcDB{db})}
+}
+
+// WithTx returns a Store that runs its queries in tx, like the WithTx of
+// the generated queries.
+func (s *Store) WithTx(tx *sql.Tx) *Store {
+	return New(tx)
+}
+
+// utcDB converts time parameters to UTC before they reach the driver.
+type utcDB struct {
+	db sqlitedb.DBTX
+}
+
+func utcArgs(args []any) []any {
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case time.Time:
+			args[i] = v.UTC()
+		case sql.NullTime:
+			v.Time = v.Time.UTC()
+			args[i] = v
+		}
+	}
+	return args
+}
+
+func (u utcDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return u.db.ExecContext(ctx, query, utcArgs(args)...)
+}
+
+func (u utcDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return u.db.PrepareContext(ctx, query)
+}
+
+func (u utcDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return u.db.QueryContext(ctx, query, utcArgs(args)...)
+}
+
+func (u utcDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return u.db.QueryRowContext(ctx, query, utcArgs(args)...)
+}
+
+// convertAll converts the rows of a :many query, keeping nil for no rows.
+func convertAll[S, D any](rows []S, convert func(S) D) []D {
+	if rows == nil {
+		return nil
+	}
+	items := make([]D, len(rows))
+	for i, row := range rows {
+		items[i] = convert(row)
+	}
+	return items
+}
@@ -0,0 +1,501 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	schema "github.com/arglp/chirpy/sql/sqlite/schema"
+	"github.com/google/uuid"
+	"github.com/pressly/goose/v3"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	return New(newTestDB(t))
+}
+
+// newTestDB opens a migrated database in a temporary directory.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := Open("sqlite:" + filepath.Join(t.TempDir(), "chirpy.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	provider, err := goose.NewProvider(goose.DialectSQLite3, db, schema.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = provider.Up(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestOpenRejectsOtherURLs(t *testing.T) {
+	for _, dbURL := range []string{"postgres://localhost/chirpy", "sqlite:", "::"} {
+		_, err := Open(dbURL)
+		if err == nil {
+			t.Errorf("Open(%q) succeeded, want an error", dbURL)
+		}
+	}
+}
+
+func TestUsersAndCascade(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	user, err := s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Role != "user" || user.IsChirpyRed {
+		t.Errorf("CreateUser = %+v, want the column defaults", user)
+	}
+	_, err = s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "y"})
+	if err == nil {
+		t.Error("duplicate email was accepted")
+	}
+	_, err = s.GetUser(ctx, "b@example.com")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUser of a missing email error = %v, want sql.ErrNoRows", err)
+	}
+
+	first, err := s.CreateChirp(ctx, database.CreateChirpParams{Body: "first", UserID: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.CreateChirp(ctx, database.CreateChirpParams{Body: "second", UserID: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chirps, err := s.GetChirps(ctx, uuid.NullUUID{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chirps) != 2 || chirps[0].ID != first.ID {
+		t.Errorf("GetChirps = %+v, want both chirps oldest first", chirps)
+	}
+	count, err := s.CountChirpsByUserSince(ctx, database.CountChirpsByUserSinceParams{
+		UserID:    user.ID,
+		CreatedAt: time.Now().Add(-time.Minute).In(time.FixedZone("UTC+5", 5*60*60)),
+	})
+	if err != nil || count != 2 {
+		t.Errorf("CountChirpsByUserSince = %d, %v, want 2 regardless of time zone", count, err)
+	}
+
+	_, err = s.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{Token: "t", UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.DeleteUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chirps, _ = s.GetChirps(ctx, uuid.NullUUID{})
+	if len(chirps) != 0 {
+		t.Errorf("GetChirps after DeleteUsers = %+v, want ON DELETE CASCADE to remove them", chirps)
+	}
+	_, err = s.GetUserFromRefreshToken(ctx, "t")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUserFromRefreshToken after DeleteUsers error = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestSubscriptionExpiry(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	user, err := s.CreateUser(ctx, database.CreateUserParams{Email: "red@example.com", HashedPassword: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.SetUserChirpyRed(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.UpsertSubscription(ctx, database.UpsertSubscriptionParams{
+		UserID:           user.ID,
+		Plan:             "chirpy_red",
+		Status:           "active",
+		CurrentPeriodEnd: time.Now().Add(-time.Minute),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := s.RevokeExpiredChirpyRed(ctx, user.ID)
+	if err != nil || revoked != 0 {
+		t.Errorf("RevokeExpiredChirpyRed() before expiry = %d, %v, want 0", revoked, err)
+	}
+
+	expired, err := s.ExpireLapsedSubscriptions(ctx)
+	if err != nil || len(expired) != 1 {
+		t.Fatalf("ExpireLapsedSubscriptions() = %d, %v, want 1", len(expired), err)
+	}
+	revoked, err = s.RevokeExpiredChirpyRed(ctx, user.ID)
+	if err != nil || revoked != 1 {
+		t.Errorf("RevokeExpiredChirpyRed() after expiry = %d, %v, want 1", revoked, err)
+	}
+	user, err = s.GetUserByID(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.IsChirpyRed {
+		t.Error("Chirpy Red was not revoked")
+	}
+}
+
+func TestStringListsAndWebhookFanOut(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	user, err := s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pat, err := s.CreatePersonalAccessToken(ctx, database.CreatePersonalAccessTokenParams{
+		UserID:    user.ID,
+		Name:      "ci",
+		TokenHash: "hash",
+		Scopes:    []string{"chirps:read", "chirps:write"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(pat.Scopes, []string{"chirps:read", "chirps:write"}) {
+		t.Errorf("Scopes = %v", pat.Scopes)
+	}
+	token, err := s.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{Token: "t", UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil || token.Scopes != nil || token.ClientID.Valid {
+		t.Errorf("CreateRefreshToken = %+v, %v, want NULL scopes and client", token, err)
+	}
+
+	for _, events := range [][]string{{"chirp.created"}, {"chirp.deleted"}} {
+		_, err = s.CreateWebhookSubscription(ctx, database.CreateWebhookSubscriptionParams{
+			UserID: user.ID,
+			Url:    "https://example.com/hook",
+			Secret: "secret",
+			Events: events,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, userID := range []uuid.UUID{user.ID, uuid.New()} {
+		err = s.EnqueueWebhookDeliveries(ctx, database.EnqueueWebhookDeliveriesParams{
+			Event:   "chirp.created",
+			Payload: json.RawMessage(`{"id":"1"}`),
+			UserID:  userID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	claimed, err := s.ClaimDueWebhookDeliveries(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 1 || string(claimed[0].Payload) != `{"id":"1"}` {
+		t.Fatalf("ClaimDueWebhookDeliveries = %+v, want the one matching delivery", claimed)
+	}
+	if !claimed[0].NextAttemptAt.After(time.Now().Add(4 * time.Minute)) {
+		t.Errorf("NextAttemptAt = %s, want a five minute lease", claimed[0].NextAttemptAt)
+	}
+	claimed, err = s.ClaimDueWebhookDeliveries(ctx, 10)
+	if err != nil || len(claimed) != 0 {
+		t.Errorf("second claim = %+v, %v, want the leased delivery skipped", claimed, err)
+	}
+}
+
+func TestReportLifecycle(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	reporter, err := s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	author, err := s.CreateUser(ctx, database.CreateUserParams{Email: "b@example.com", HashedPassword: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chirp, err := s.CreateChirp(ctx, database.CreateChirpParams{Body: "spam", UserID: author.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := s.CreateReport(ctx, database.CreateReportParams{
+		ReporterID:   reporter.ID,
+		TargetUserID: author.ID,
+		ChirpID:      uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		ChirpBody:    sql.NullString{String: chirp.Body, Valid: true},
+		Reason:       "spam",
+	})
+	if err != nil || report.Status != "open" {
+		t.Fatalf("CreateReport = %+v, %v, want an open report", report, err)
+	}
+	_, err = s.CreateReport(ctx, database.CreateReportParams{ReporterID: reporter.ID, TargetUserID: author.ID, Reason: "boring"})
+	if err == nil {
+		t.Error("a report with an unknown reason was accepted")
+	}
+
+	moderator := uuid.NullUUID{UUID: reporter.ID, Valid: true}
+	_, err = s.ResolveReport(ctx, database.ResolveReportParams{ID: report.ID, ClaimedBy: moderator, Resolution: sql.NullString{String: "dismiss", Valid: true}})
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("ResolveReport of an open report error = %v, want sql.ErrNoRows", err)
+	}
+	claimed, err := s.ClaimReport(ctx, database.ClaimReportParams{ID: report.ID, ClaimedBy: moderator})
+	if err != nil || claimed.Status != "claimed" || !claimed.ClaimedAt.Valid {
+		t.Fatalf("ClaimReport = %+v, %v", claimed, err)
+	}
+	_, err = s.ClaimReport(ctx, database.ClaimReportParams{ID: report.ID, ClaimedBy: moderator})
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("second ClaimReport error = %v, want sql.ErrNoRows", err)
+	}
+	unclaimed, err := s.UnclaimReport(ctx, database.UnclaimReportParams{ID: report.ID, ClaimedBy: moderator})
+	if err != nil || unclaimed.Status != "open" || unclaimed.ClaimedBy.Valid || unclaimed.ClaimedAt.Valid {
+		t.Fatalf("UnclaimReport = %+v, %v", unclaimed, err)
+	}
+	_, err = s.ClaimReport(ctx, database.ClaimReportParams{ID: report.ID, ClaimedBy: moderator})
+	if err != nil {
+		t.Fatalf("ClaimReport after unclaim error = %v", err)
+	}
+	resolved, err := s.ResolveReport(ctx, database.ResolveReportParams{ID: report.ID, ClaimedBy: moderator, Resolution: sql.NullString{String: "hide_chirp", Valid: true}})
+	if err != nil || resolved.Status != "resolved" || resolved.Resolution.String != "hide_chirp" {
+		t.Errorf("ResolveReport = %+v, %v", resolved, err)
+	}
+
+	err = s.HideChirp(ctx, chirp.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chirps, _ := s.GetChirps(ctx, uuid.NullUUID{})
+	if len(chirps) != 0 {
+		t.Errorf("GetChirps = %+v, want the hidden chirp left out", chirps)
+	}
+	chirps, _ = s.GetChirps(ctx, uuid.NullUUID{UUID: author.ID, Valid: true})
+	if len(chirps) != 1 {
+		t.Errorf("GetChirps for the author = %+v, want the hidden chirp listed", chirps)
+	}
+	err = s.DeleteChirpByID(ctx, chirp.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err = s.GetReport(ctx, report.ID)
+	if err != nil || report.ChirpID.Valid || report.ChirpBody.String != "spam" {
+		t.Errorf("GetReport after deleting the chirp = %+v, %v, want the chirp cleared and its body kept", report, err)
+	}
+}
+
+func TestShadowbannedChirps(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	user, err := s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.CreateChirp(ctx, database.CreateChirpParams{Body: "hello", UserID: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err = s.SetUserShadowbanned(ctx, database.SetUserShadowbannedParams{ID: user.ID, Shadowbanned: true})
+	if err != nil || !user.Shadowbanned {
+		t.Fatalf("SetUserShadowbanned = %+v, %v", user, err)
+	}
+
+	chirps, err := s.GetChirps(ctx, uuid.NullUUID{})
+	if err != nil || len(chirps) != 0 {
+		t.Errorf("GetChirps for an anonymous viewer = %+v, %v, want none", chirps, err)
+	}
+	chirps, err = s.GetChirps(ctx, uuid.NullUUID{UUID: user.ID, Valid: true})
+	if err != nil || len(chirps) != 1 {
+		t.Errorf("GetChirps for the author = %+v, %v, want their chirp", chirps, err)
+	}
+}
+
+func TestBlockedAndMutedChirps(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	var users []database.User
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		user, err := s.CreateUser(ctx, database.CreateUserParams{Email: email, HashedPassword: "x"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = s.CreateChirp(ctx, database.CreateChirpParams{Body: "hello", UserID: user.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		users = append(users, user)
+	}
+	a, b, c := users[0], users[1], users[2]
+	if err := s.BlockUser(ctx, database.BlockUserParams{BlockerID: a.ID, BlockedID: b.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MuteUser(ctx, database.MuteUserParams{MuterID: a.ID, MutedID: c.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BlockUser(ctx, database.BlockUserParams{BlockerID: a.ID, BlockedID: a.ID}); err == nil {
+		t.Error("BlockUser let a user block themselves")
+	}
+
+	n, err := s.CountBlocksBetween(ctx, database.CountBlocksBetweenParams{UserID: b.ID, OtherUserID: a.ID})
+	if err != nil || n != 1 {
+		t.Errorf("CountBlocksBetween = %d, %v, want 1", n, err)
+	}
+	for _, tc := range []struct {
+		viewer database.User
+		want   int
+	}{{a, 1}, {b, 2}, {c, 3}} {
+		chirps, err := s.GetChirps(ctx, uuid.NullUUID{UUID: tc.viewer.ID, Valid: true})
+		if err != nil || len(chirps) != tc.want {
+			t.Errorf("GetChirps for %s = %d chirps, %v, want %d", tc.viewer.Email, len(chirps), err, tc.want)
+		}
+	}
+
+	// Deleting the blocked user removes the block with them.
+	if err := s.DeleteUsers(ctx); err != nil {
+		t.Fatal(err)
+	}
+	blocks, err := s.GetBlocksForUser(ctx, a.ID)
+	if err != nil || len(blocks) != 0 {
+		t.Errorf("GetBlocksForUser after deleting users = %+v, %v", blocks, err)
+	}
+}
+
+func TestAuditEventsAreAppendOnly(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	s := New(db)
+
+	actor := uuid.New()
+	for _, action := range []string{"chirp.deleted", "admin.reset"} {
+		err := s.CreateAuditEvent(ctx, database.CreateAuditEventParams{
+			ActorID:     uuid.NullUUID{UUID: actor, Valid: action == "chirp.deleted"},
+			Action:      action,
+			TargetType:  "system",
+			BeforeState: json.RawMessage(`{"body":"hi"}`),
+			AfterState:  json.RawMessage("null"),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	events, err := s.ListAuditEvents(ctx, database.ListAuditEventsParams{
+		ActorID: uuid.NullUUID{UUID: actor, Valid: true},
+		Since:   sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true},
+		Until:   sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+		Limit:   10,
+	})
+	if err != nil || len(events) != 1 || string(events[0].BeforeState) != `{"body":"hi"}` {
+		t.Fatalf("ListAuditEvents = %+v, %v, want the actor's event", events, err)
+	}
+	events, _ = s.ListAuditEvents(ctx, database.ListAuditEventsParams{
+		Since: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+		Limit: 10,
+	})
+	if len(events) != 0 {
+		t.Errorf("ListAuditEvents since a minute from now = %+v, want none", events)
+	}
+
+	_, err = db.ExecContext(ctx, "UPDATE audit_events SET action = 'nothing'")
+	if err == nil {
+		t.Error("an audit event was updated")
+	}
+	_, err = db.ExecContext(ctx, "DELETE FROM audit_events")
+	if err == nil {
+		t.Error("an audit event was deleted")
+	}
+}
+
+func TestAccountDeletionAndDataExports(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	user, err := s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.CreateChirp(ctx, database.CreateChirpParams{Body: "hello", UserID: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	export, err := s.CreateDataExport(ctx, user.ID)
+	if err != nil || export.Status != "pending" {
+		t.Fatalf("CreateDataExport = %+v, %v", export, err)
+	}
+	claimed, err := s.ClaimPendingDataExports(ctx, 10)
+	if err != nil || len(claimed) != 1 || claimed[0].Status != "running" {
+		t.Fatalf("ClaimPendingDataExports = %+v, %v, want the export running", claimed, err)
+	}
+	claimed, _ = s.ClaimPendingDataExports(ctx, 10)
+	if len(claimed) != 0 {
+		t.Errorf("a running export was claimed twice: %+v", claimed)
+	}
+	err = s.FinishDataExport(ctx, database.FinishDataExportParams{
+		ID:        export.ID,
+		Status:    "ready",
+		Archive:   []byte("PK"),
+		ExpiresAt: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	export, err = s.GetLatestDataExportForUser(ctx, user.ID)
+	if err != nil || export.Status != "ready" || string(export.Archive) != "PK" || !export.CompletedAt.Valid {
+		t.Errorf("GetLatestDataExportForUser = %+v, %v", export, err)
+	}
+	if n, err := s.DeleteExpiredDataExports(ctx); err != nil || n != 0 {
+		t.Errorf("DeleteExpiredDataExports = %d, %v, want nothing expired", n, err)
+	}
+
+	user, err = s.ScheduleUserDeletion(ctx, database.ScheduleUserDeletionParams{
+		ID:                  user.ID,
+		DeletionScheduledAt: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+	})
+	if err != nil || !user.DeletionScheduledAt.Valid {
+		t.Fatalf("ScheduleUserDeletion = %+v, %v", user, err)
+	}
+	due, err := s.GetUsersDueForDeletion(ctx)
+	if err != nil || len(due) != 0 {
+		t.Errorf("GetUsersDueForDeletion before the grace period ends = %+v, %v", due, err)
+	}
+	deleted, err := s.DeleteUserIfDue(ctx, user.ID)
+	if err != nil || deleted != 0 {
+		t.Errorf("DeleteUserIfDue before the grace period ends = %d, %v, want 0", deleted, err)
+	}
+	_, err = s.ScheduleUserDeletion(ctx, database.ScheduleUserDeletionParams{
+		ID:                  user.ID,
+		DeletionScheduledAt: sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	due, err = s.GetUsersDueForDeletion(ctx)
+	if err != nil || len(due) != 1 || due[0].ID != user.ID {
+		t.Fatalf("GetUsersDueForDeletion = %+v, %v, want the user", due, err)
+	}
+
+	deleted, err = s.DeleteUserIfDue(ctx, user.ID)
+	if err != nil || deleted != 1 {
+		t.Fatalf("DeleteUserIfDue = %d, %v, want 1", deleted, err)
+	}
+	chirps, _ := s.GetChirpsByUser(ctx, user.ID)
+	_, err = s.GetLatestDataExportForUser(ctx, user.ID)
+	if len(chirps) != 0 || !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("after DeleteUser: chirps = %+v, latest export error = %v", chirps, err)
+	}
+}
@@ -0,0 +1,43 @@
+package sqlitestore
+
+import (
+	"context"
+
+	"github.com/arglp/chirpy/internal/database"
+	sqlitedb "github.com/arglp/chirpy/internal/database/sqlite"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateSubscriptionEvent(ctx context.Context, arg database.CreateSubscriptionEventParams) error {
+	return s.q.CreateSubscriptionEvent(ctx, sqlitedb.CreateSubscriptionEventParams(arg))
+}
+
+func (s *Store) ExpireLapsedSubscriptions(ctx context.Context) ([]database.Subscription, error) {
+	subscriptions, err := s.q.ExpireLapsedSubscriptions(ctx)
+	return convertAll(subscriptions, toSubscription), err
+}
+
+func (s *Store) GetSubscriptionByUser(ctx context.Context, userID uuid.UUID) (database.Subscription, error) {
+	subscription, err := s.q.GetSubscriptionByUser(ctx, userID)
+	return database.Subscription(subscription), err
+}
+
+func (s *Store) GetSubscriptionEvents(ctx context.Context, subscriptionID uuid.UUID) ([]database.SubscriptionEvent, error) {
+	events, err := s.q.GetSubscriptionEvents(ctx, subscriptionID)
+	return convertAll(events, func(e sqlitedb.SubscriptionEvent) database.SubscriptionEvent {
+		return database.SubscriptionEvent(e)
+	}), err
+}
+
+func (s *Store) RevokeExpiredChirpyRed(ctx context.Context, id uuid.UUID) (int64, error) {
+	return s.q.RevokeExpiredChirpyRed(ctx, id)
+}
+
+func (s *Store) UpsertSubscription(ctx context.Context, arg database.UpsertSubscriptionParams) (database.Subscription, error) {
+	subscription, err := s.q.UpsertSubscription(ctx, sqlitedb.UpsertSubscriptionParams(arg))
+	return database.Subscription(subscription), err
+}
+
+func toSubscription(s sqlitedb.Subscription) database.Subscription {
+	return database.Subscription(s)
+}
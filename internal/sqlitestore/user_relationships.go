@@ -0,0 +1,43 @@
+package sqlitestore
+
+import (
+	"context"
+
+	"github.com/arglp/chirpy/internal/database"
+	sqlitedb "github.com/arglp/chirpy/internal/database/sqlite"
+	"github.com/google/uuid"
+)
+
+func (s *Store) BlockUser(ctx context.Context, arg database.BlockUserParams) error {
+	return s.q.BlockUser(ctx, sqlitedb.BlockUserParams(arg))
+}
+
+func (s *Store) CountBlocksBetween(ctx context.Context, arg database.CountBlocksBetweenParams) (int64, error) {
+	return s.q.CountBlocksBetween(ctx, sqlitedb.CountBlocksBetweenParams(arg))
+}
+
+func (s *Store) GetBlocksForUser(ctx context.Context, blockerID uuid.UUID) ([]database.UserBlock, error) {
+	blocks, err := s.q.GetBlocksForUser(ctx, blockerID)
+	return convertAll(blocks, func(b sqlitedb.UserBlock) database.UserBlock {
+		return database.UserBlock(b)
+	}), err
+}
+
+func (s *Store) GetMutesForUser(ctx context.Context, muterID uuid.UUID) ([]database.UserMute, error) {
+	mutes, err := s.q.GetMutesForUser(ctx, muterID)
+	return convertAll(mutes, func(m sqlitedb.UserMute) database.UserMute {
+		return database.UserMute(m)
+	}), err
+}
+
+func (s *Store) MuteUser(ctx context.Context, arg database.MuteUserParams) error {
+	return s.q.MuteUser(ctx, sqlitedb.MuteUserParams(arg))
+}
+
+func (s *Store) UnblockUser(ctx context.Context, arg database.UnblockUserParams) error {
+	return s.q.UnblockUser(ctx, sqlitedb.UnblockUserParams(arg))
+}
+
+func (s *Store) UnmuteUser(ctx context.Context, arg database.UnmuteUserParams) error {
+	return s.q.UnmuteUser(ctx, sqlitedb.UnmuteUserParams(arg))
+}
@@ -0,0 +1,88 @@
+package sqlitestore
+
+import (
+	"context"
+
+	"github.com/arglp/chirpy/internal/database"
+	sqlitedb "github.com/arglp/chirpy/internal/database/sqlite"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	user, err := s.q.CreateUser(ctx, sqlitedb.CreateUserParams(arg))
+	return database.User(user), err
+}
+
+func (s *Store) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	return s.q.DeleteUser(ctx, id)
+}
+
+func (s *Store) DeleteUserIfDue(ctx context.Context, id uuid.UUID) (int64, error) {
+	return s.q.DeleteUserIfDue(ctx, id)
+}
+
+func (s *Store) DeleteUsers(ctx context.Context) error {
+	return s.q.DeleteUsers(ctx)
+}
+
+func (s *Store) GetUser(ctx context.Context, email string) (database.User, error) {
+	user, err := s.q.GetUser(ctx, email)
+	return database.User(user), err
+}
+
+func (s *Store) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	user, err := s.q.GetUserByID(ctx, id)
+	return database.User(user), err
+}
+
+func (s *Store) GetUserPasswordHashes(ctx context.Context) ([]database.GetUserPasswordHashesRow, error) {
+	rows, err := s.q.GetUserPasswordHashes(ctx)
+	return convertAll(rows, func(r sqlitedb.GetUserPasswordHashesRow) database.GetUserPasswordHashesRow {
+		return database.GetUserPasswordHashesRow(r)
+	}), err
+}
+
+func (s *Store) GetUsersDueForDeletion(ctx context.Context) ([]database.User, error) {
+	users, err := s.q.GetUsersDueForDeletion(ctx)
+	return convertAll(users, func(u sqlitedb.User) database.User {
+		return database.User(u)
+	}), err
+}
+
+func (s *Store) ScheduleUserDeletion(ctx context.Context, arg database.ScheduleUserDeletionParams) (database.User, error) {
+	user, err := s.q.ScheduleUserDeletion(ctx, sqlitedb.ScheduleUserDeletionParams(arg))
+	return database.User(user), err
+}
+
+func (s *Store) SetUserChirpyRed(ctx context.Context, id uuid.UUID) (database.User, error) {
+	user, err := s.q.SetUserChirpyRed(ctx, id)
+	return database.User(user), err
+}
+
+func (s *Store) SetUserChirpyRedStatus(ctx context.Context, arg database.SetUserChirpyRedStatusParams) error {
+	return s.q.SetUserChirpyRedStatus(ctx, sqlitedb.SetUserChirpyRedStatusParams(arg))
+}
+
+func (s *Store) SetUserEmailPassword(ctx context.Context, arg database.SetUserEmailPasswordParams) (database.User, error) {
+	user, err := s.q.SetUserEmailPassword(ctx, sqlitedb.SetUserEmailPasswordParams(arg))
+	return database.User(user), err
+}
+
+func (s *Store) SetUserPassword(ctx context.Context, arg database.SetUserPasswordParams) error {
+	return s.q.SetUserPassword(ctx, sqlitedb.SetUserPasswordParams(arg))
+}
+
+func (s *Store) SetUserRole(ctx context.Context, arg database.SetUserRoleParams) (database.User, error) {
+	user, err := s.q.SetUserRole(ctx, sqlitedb.SetUserRoleParams(arg))
+	return database.User(user), err
+}
+
+func (s *Store) SetUserShadowbanned(ctx context.Context, arg database.SetUserShadowbannedParams) (database.User, error) {
+	user, err := s.q.SetUserShadowbanned(ctx, sqlitedb.SetUserShadowbannedParams(arg))
+	return database.User(user), err
+}
+
+func (s *Store) SuspendUser(ctx context.Context, arg database.SuspendUserParams) (database.User, error) {
+	user, err := s.q.SuspendUser(ctx, sqlitedb.SuspendUserParams(arg))
+	return database.User(user), err
+}
@@ -0,0 +1,16 @@
+package sqlitestore
+
+import (
+	"context"
+
+	"github.com/arglp/chirpy/internal/database"
+	sqlitedb "github.com/arglp/chirpy/internal/database/sqlite"
+)
+
+func (s *Store) ClaimWebhookEvent(ctx context.Context, arg database.ClaimWebhookEventParams) (int64, error) {
+	return s.q.ClaimWebhookEvent(ctx, sqlitedb.ClaimWebhookEventParams(arg))
+}
+
+func (s *Store) ReleaseWebhookEvent(ctx context.Context, eventID string) error {
+	return s.q.ReleaseWebhookEvent(ctx, eventID)
+}
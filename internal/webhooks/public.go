@@ -0,0 +1,67 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrNonPublicAddress is returned when a receiver resolves to an address
+// that isn't on the public internet.
+var ErrNonPublicAddress = errors.New("webhooks: receiver address is not public")
+
+// sharedAddressSpace is the carrier-grade NAT range, 100.64.0.0/10, which
+// netip doesn't count as private.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// PublicAddr reports whether addr is a public unicast address. Loopback,
+// private, link-local (cloud metadata endpoints included), multicast and
+// unspecified addresses are not.
+func PublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+// LookupPublic resolves host and fails unless every address it resolves to
+// is public.
+func LookupPublic(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !PublicAddr(addr) {
+			return ErrNonPublicAddress
+		}
+	}
+	return nil
+}
+
+// PublicClient returns a client that only connects to public addresses.
+// The check runs on the address actually dialed, so a hostname that
+// resolves differently at delivery time than it did at registration, or a
+// redirect, can't reach internal services either. Proxies from the
+// environment are ignored for the same reason.
+func PublicClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || !PublicAddr(addrPort.Addr()) {
+				return ErrNonPublicAddress
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
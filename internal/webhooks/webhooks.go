@@ -0,0 +1,96 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/arglp/chirpy/internal/auth"
+)
+
+const (
+	EventChirpCreated = "chirp.created"
+	EventChirpUpdated = "chirp.updated"
+	EventChirpDeleted = "chirp.deleted"
+)
+
+var Events = []string{EventChirpCreated, EventChirpUpdated, EventChirpDeleted}
+
+// MaxAttempts is how often a delivery is tried before it is marked failed.
+const MaxAttempts = 8
+
+const (
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 6 * time.Hour
+)
+
+func ValidEvent(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Backoff returns how long to wait after the given failed attempt (counting
+// from 1) before trying again: 30s, 1m, 2m, ... capped at six hours.
+func Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	backoff := baseBackoff
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if backoff >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return backoff
+}
+
+type Delivery struct {
+	ID      string
+	Event   string
+	URL     string
+	Secret  string
+	Payload []byte
+}
+
+type Sender struct {
+	Client *http.Client
+}
+
+// Send POSTs a delivery to its URL, signed with the subscription secret in
+// the X-Chirpy-Signature header. Any 2xx response counts as delivered; the
+// status code is returned whenever the receiver answered.
+func (s Sender) Send(ctx context.Context, d Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Chirpy-Webhooks/1.0")
+	req.Header.Set("X-Chirpy-Event", d.Event)
+	req.Header.Set("X-Chirpy-Delivery", d.ID)
+	req.Header.Set("X-Chirpy-Signature", auth.SignWebhookPayload(d.Payload, d.Secret, time.Now()))
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,120 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/arglp/chirpy/internal/auth"
+)
+
+func TestSend(t *testing.T) {
+	secret := "whsec_test"
+	payload := []byte(`{"event":"chirp.created"}`)
+
+	var gotEvent string
+	var gotSignatureErr error
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotEvent = r.Header.Get("X-Chirpy-Event")
+		gotSignatureErr = auth.VerifyWebhookSignature(r.Header.Get("X-Chirpy-Signature"), body, secret, time.Minute, time.Now())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	status, err := Sender{Client: receiver.Client()}.Send(context.Background(), Delivery{
+		ID:      "delivery-1",
+		Event:   EventChirpCreated,
+		URL:     receiver.URL,
+		Secret:  secret,
+		Payload: payload,
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if status != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", status, http.StatusNoContent)
+	}
+	if gotEvent != EventChirpCreated {
+		t.Fatalf("X-Chirpy-Event = %q", gotEvent)
+	}
+	if gotSignatureErr != nil {
+		t.Fatalf("receiver couldn't verify signature: %v", gotSignatureErr)
+	}
+}
+
+func TestSendNon2xx(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	status, err := Sender{Client: receiver.Client()}.Send(context.Background(), Delivery{
+		Event:   EventChirpDeleted,
+		URL:     receiver.URL,
+		Secret:  "whsec_test",
+		Payload: []byte(`{}`),
+	})
+	if err == nil {
+		t.Fatalf("Send() expected error for 503")
+	}
+	if status != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", status, http.StatusServiceUnavailable)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: 30 * time.Second},
+		{attempt: 2, want: time.Minute},
+		{attempt: 3, want: 2 * time.Minute},
+		{attempt: 20, want: 6 * time.Hour},
+	}
+	for _, tc := range tests {
+		if got := Backoff(tc.attempt); got != tc.want {
+			t.Fatalf("Backoff(%d) = %v, want %v", tc.attempt, got, tc.want)
+		}
+	}
+}
+
+func TestPublicAddr(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"::1":              false,
+		"fe80::1":          false,
+		"fd00::1":          false,
+		"::ffff:127.0.0.1": false,
+	} {
+		if got := PublicAddr(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("PublicAddr(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestPublicClientRefusesLoopback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached a loopback receiver")
+	}))
+	defer srv.Close()
+
+	_, err := Sender{Client: PublicClient(time.Second)}.Send(context.Background(), Delivery{URL: srv.URL, Payload: []byte("{}")})
+	if !errors.Is(err, ErrNonPublicAddress) {
+		t.Errorf("Send to loopback = %v, want ErrNonPublicAddress", err)
+	}
+}
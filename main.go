@@ -1,64 +1,181 @@
 package main
 
 import (
+	"context"
 	"net/http"
-	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"database/sql"
+	"time"
+	"github.com/arglp/chirpy/internal/config"
 	"github.com/arglp/chirpy/internal/database"
-	"github.com/joho/godotenv"
+	"github.com/arglp/chirpy/internal/sqlitestore"
+	"github.com/arglp/chirpy/internal/webhooks"
+	"github.com/arglp/chirpy/sql/schema"
+	sqliteschema "github.com/arglp/chirpy/sql/sqlite/schema"
 	_ "github.com/lib/pq"
 )
 
 func main () {
 
-	godotenv.Load()
-	dbURL := os.Getenv("DB_URL")
+	conf, err := config.Load()
+	if err != nil {
+		slog.Error("fatal error", "error", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(newLogger(os.Stderr, conf.LogLevel))
+
+	var db *sql.DB
+	if conf.DBDriver == config.DBDriverSQLite {
+		db, err = sqlitestore.Open(conf.DBURL)
+	} else {
+		db, err = sql.Open("postgres", conf.DBURL)
+	}
+	if err != nil {
+		slog.Error("fatal error", "error", err)
+		os.Exit(1)
+	}
+	db.SetMaxOpenConns(conf.DBMaxOpenConns)
+	db.SetMaxIdleConns(conf.DBMaxIdleConns)
+	db.SetConnMaxLifetime(conf.DBConnMaxLifetime)
+	if conf.DBDriver == config.DBDriverSQLite {
+		// SQLite takes one writer at a time. A single connection queues
+		// writes in the pool instead of failing them with SQLITE_BUSY.
+		db.SetMaxOpenConns(1)
+	}
 
-	db, err := sql.Open("postgres", dbURL)
+	pingCtx, cancelPing := context.WithTimeout(context.Background(), 5*time.Second)
+	err = db.PingContext(pingCtx)
+	cancelPing()
 	if err != nil {
-		log.Fatal("fatal error: ", err)
+		slog.Error("couldn't reach database", "error", err)
+		os.Exit(1)
 	}
 
 	var apiCfg apiConfig
 	apiCfg.fileserverHits.Store(0)
-	apiCfg.dbQueries = database.New(db)
-	apiCfg.platform = os.Getenv("PLATFORM")
-	apiCfg.secret = os.Getenv("SECRET")
-	apiCfg.polkaKey = os.Getenv("POLKA_KEY")
+	apiCfg.db = db
+	apiCfg.dbDriver = conf.DBDriver
+	if conf.DBDriver == config.DBDriverSQLite {
+		apiCfg.dbQueries = sqlitestore.New(db)
+		apiCfg.migrations = sqliteschema.FS
+	} else {
+		apiCfg.dbQueries = database.New(db)
+		apiCfg.migrations = schema.FS
+	}
+	apiCfg.heartbeats = newWorkerHeartbeats()
+	apiCfg.platform = conf.Platform
+	apiCfg.secret = conf.Secret
+	apiCfg.polkaKey = conf.PolkaKey
+	apiCfg.polkaWebhookSecret = conf.PolkaWebhookSecret
+	apiCfg.webhookSender = webhooks.Sender{Client: webhooks.PublicClient(10 * time.Second)}
+	if apiCfg.platform == config.PlatformDev {
+		apiCfg.webhookSender.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	apiCfg.mailer = newMailer(conf.SMTP, conf.Platform)
+	apiCfg.magicLinkURL = conf.MagicLinkURL
+	apiCfg.passwordParams = conf.PasswordParams
+	apiCfg.accessTokenTTL = conf.AccessTokenTTL
+	apiCfg.refreshTokenTTL = conf.RefreshTokenTTL
+	apiCfg.magicLinkTTL = conf.MagicLinkTTL
+	apiCfg.accountDeletionGracePeriod = conf.AccountDeletionGracePeriod
+	apiCfg.dataExportTTL = conf.DataExportTTL
+
+	if len(os.Args) > 1 {
+		err = apiCfg.runCommand(os.Args[1:])
+		if err != nil {
+			slog.Error("fatal error", "error", err)
+		os.Exit(1)
+		}
+		return
+	}
 
-	mux := http.NewServeMux()
+	if conf.AutoMigrate {
+		err = apiCfg.migrateUp(context.Background())
+		if err != nil {
+			slog.Error("couldn't apply migrations", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	if conf.BootstrapAdminEmail != "" {
+		err = apiCfg.bootstrapAdmin(context.Background(), conf.BootstrapAdminEmail)
+		if err != nil {
+			slog.Error("couldn't bootstrap admin", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	apiCfg.metrics = newServerMetrics(db)
 
 	s := &http.Server{}
-	s.Addr = ":8080"
-	s.Handler = mux
-
-	fileServer := http.FileServer(http.Dir("."))
-	fsHandler := apiCfg.middlewareMetricsInc(http.StripPrefix("/app", fileServer))
-	
-	mux.Handle("/app/", fsHandler)
-
-	mux.HandleFunc("GET /api/healthz", func(w http.ResponseWriter, req *http.Request) {
-		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-		w.WriteHeader(200)
-		w.Write([]byte("OK\n"))
-	})
-
-	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
-	mux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
-	mux.HandleFunc("POST /api/users", apiCfg.handlerUsers)
-	mux.HandleFunc("POST /api/chirps", apiCfg.handlerPostChirps)
-	mux.HandleFunc("GET /api/chirps", apiCfg.handlerGetChirps)
-	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.handlerGetChirpByID)
-	mux.HandleFunc("POST /api/login", apiCfg.handlerLogin)
-	mux.HandleFunc("POST /api/refresh", apiCfg.handlerRefresh)
-	mux.HandleFunc("POST /api/revoke", apiCfg.handlerRevoke)
-	mux.HandleFunc("PUT /api/users", apiCfg.handlerUpdateUser)
-	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.handlerDeleteChirp)
-	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerPolkaWebhook)
-
-	err = s.ListenAndServe()
+	s.Addr = conf.ListenAddr
+	s.Handler = apiCfg.routes()
+	s.ReadTimeout = conf.ReadTimeout
+	s.ReadHeaderTimeout = conf.ReadHeaderTimeout
+	s.WriteTimeout = conf.WriteTimeout
+	s.IdleTimeout = conf.IdleTimeout
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	workers.Add(4)
+	apiCfg.heartbeats.register(workerSubscriptionExpiry, conf.SubscriptionExpiryInterval)
+	apiCfg.heartbeats.register(workerWebhookDelivery, conf.WebhookDeliveryInterval)
+	apiCfg.heartbeats.register(workerAccountPurge, conf.AccountPurgeInterval)
+	apiCfg.heartbeats.register(workerDataExport, conf.DataExportInterval)
+	go func() {
+		defer workers.Done()
+		apiCfg.runSubscriptionExpiry(workerCtx, conf.SubscriptionExpiryInterval)
+	}()
+	go func() {
+		defer workers.Done()
+		apiCfg.runWebhookDelivery(workerCtx, conf.WebhookDeliveryInterval)
+	}()
+	go func() {
+		defer workers.Done()
+		apiCfg.runAccountPurge(workerCtx, conf.AccountPurgeInterval)
+	}()
+	go func() {
+		defer workers.Done()
+		apiCfg.runDataExport(workerCtx, conf.DataExportInterval)
+	}()
+
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("server starting", "addr", s.Addr, "platform", apiCfg.platform)
+		serverErr <- s.ListenAndServe()
+	}()
+
+	select {
+	case err = <-serverErr:
+		slog.Error("fatal error", "error", err)
+		os.Exit(1)
+	case <-signalCtx.Done():
+	}
+
+	// Stop accepting connections and give in-flight requests the drain
+	// period to finish before the workers and the database pool go away.
+	slog.Info("shutting down", "drain", conf.ShutdownTimeout)
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), conf.ShutdownTimeout)
+	defer cancelShutdown()
+	err = s.Shutdown(shutdownCtx)
+	if err != nil {
+		slog.Error("couldn't drain connections", "error", err)
+	}
+
+	stopWorkers()
+	workers.Wait()
+	apiCfg.background.Wait()
+
+	err = db.Close()
 	if err != nil {
-		log.Fatal("fatal error:", err)
+		slog.Error("couldn't close database", "error", err)
 	}
+	slog.Info("server stopped")
 }
\ No newline at end of file
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/arglp/chirpy/internal/auth"
+	"github.com/google/uuid"
+)
+
+const (
+	authMethodJWT   = "jwt"
+	authMethodOAuth = "oauth"
+	authMethodPAT   = "pat"
+)
+
+// principal is the authenticated caller of a request. Scopes only apply when
+// Scoped is set; first-party JWTs may do anything the user can.
+type principal struct {
+	UserID   uuid.UUID
+	Method   string
+	ClientID uuid.UUID
+	Scopes   []string
+	Scoped   bool
+}
+
+func (p principal) hasScope(scope string) bool {
+	return scope == "" || !p.Scoped || auth.HasScope(p.Scopes, scope)
+}
+
+type contextKey string
+
+const principalContextKey contextKey = "principal"
+
+func principalFromContext(ctx context.Context) (principal, bool) {
+	p, ok := ctx.Value(principalContextKey).(principal)
+	return p, ok
+}
+
+// resolvePrincipal turns a bearer token into a principal. Tokens with the
+// personal access token prefix are looked up by hash; everything else must
+// be an access JWT.
+func (cfg *apiConfig) resolvePrincipal(ctx context.Context, token string) (principal, error) {
+	if !auth.IsPersonalAccessToken(token) {
+		accessToken, err := auth.ParseAccessToken(token, cfg.secret)
+		if err != nil {
+			return principal{}, err
+		}
+		if accessToken.Scoped {
+			return principal{
+				UserID:   accessToken.UserID,
+				Method:   authMethodOAuth,
+				ClientID: accessToken.ClientID,
+				Scopes:   accessToken.Scopes,
+				Scoped:   true,
+			}, nil
+		}
+		return principal{UserID: accessToken.UserID, Method: authMethodJWT}, nil
+	}
+
+	pat, err := cfg.dbQueries.GetPersonalAccessTokenByHash(ctx, auth.HashToken(token))
+	if err != nil {
+		return principal{}, errors.New("unknown personal access token")
+	}
+	if pat.RevokedAt.Valid {
+		return principal{}, errors.New("personal access token revoked")
+	}
+	if pat.ExpiresAt.Valid && time.Now().After(pat.ExpiresAt.Time) {
+		return principal{}, errors.New("personal access token expired")
+	}
+
+	err = cfg.dbQueries.TouchPersonalAccessToken(ctx, pat.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "couldn't update token last use", "token_id", pat.ID, "error", err)
+	}
+	return principal{
+		UserID: pat.UserID,
+		Method: authMethodPAT,
+		Scopes: pat.Scopes,
+		Scoped: true,
+	}, nil
+}
+
+func respondUnauthorized(w http.ResponseWriter, description string) {
+	challenge := `Bearer realm="chirpy"`
+	if description != "" {
+		challenge += fmt.Sprintf(`, error="invalid_token", error_description=%q`, description)
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	respondWithError(w, http.StatusUnauthorized, "unauthorized")
+}
+
+func respondInsufficientScope(w http.ResponseWriter, scope string) {
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="chirpy", error="insufficient_scope", scope=%q`, scope))
+	respondWithError(w, http.StatusForbidden, "token lacks the required scope: "+scope)
+}
+
+// authenticateRequest stores the principal of r in its context. Requests
+// without an Authorization header only pass when authRequired is false; a
+// header that is present must always be valid, carry scope and belong to a
+// user who isn't suspended.
+func (cfg *apiConfig) authenticateRequest(w http.ResponseWriter, r *http.Request, authRequired bool, scope string) (*http.Request, bool) {
+	if r.Header.Get("Authorization") == "" {
+		if authRequired {
+			respondUnauthorized(w, "")
+			return nil, false
+		}
+		return r, true
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondUnauthorized(w, "malformed authorization header")
+		return nil, false
+	}
+	p, err := cfg.resolvePrincipal(r.Context(), token)
+	if err != nil {
+		respondUnauthorized(w, "invalid or expired token")
+		return nil, false
+	}
+	if !p.hasScope(scope) {
+		respondInsufficientScope(w, scope)
+		return nil, false
+	}
+
+	// Access JWTs outlive a suspension, so it is checked on every request.
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), p.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondUnauthorized(w, "user no longer exists")
+		return nil, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't look up user")
+		return nil, false
+	}
+	if respondIfSuspended(w, user) {
+		return nil, false
+	}
+	return r.WithContext(context.WithValue(r.Context(), principalContextKey, p)), true
+}
+
+// requireAuth rejects requests without a valid token granting scope. An
+// empty scope accepts any authenticated caller.
+func (cfg *apiConfig) requireAuth(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r, ok := cfg.authenticateRequest(w, r, true, scope)
+		if !ok {
+			return
+		}
+		next(w, r)
+	}
+}
+
+// optionalAuth lets anonymous requests through, but still rejects invalid
+// tokens so callers notice them.
+func (cfg *apiConfig) optionalAuth(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r, ok := cfg.authenticateRequest(w, r, false, scope)
+		if !ok {
+			return
+		}
+		next(w, r)
+	}
+}
+
+// requireLogin only accepts first-party access JWTs from a password or
+// magic-link login. It guards credential management, so a leaked personal
+// access token or OAuth token can't be used to mint more credentials.
+func (cfg *apiConfig) requireLogin(next http.HandlerFunc) http.HandlerFunc {
+	return cfg.requireAuth("", func(w http.ResponseWriter, r *http.Request) {
+		p, _ := principalFromContext(r.Context())
+		if p.Method != authMethodJWT {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="chirpy", error="insufficient_scope"`)
+			respondWithError(w, http.StatusForbidden, "this endpoint requires a login access token")
+			return
+		}
+		next(w, r)
+	})
+}
+
+// roleRank orders the roles so that each one includes the powers of the
+// roles below it.
+var roleRank = map[string]int{
+	roleUser:      0,
+	roleModerator: 1,
+	roleAdmin:     2,
+}
+
+func hasRole(role, required string) bool {
+	rank, ok := roleRank[role]
+	return ok && rank >= roleRank[required]
+}
+
+// requireRole only accepts login access JWTs of users holding at least role.
+// The role is read on every request rather than carried in the token, so a
+// demotion takes effect immediately.
+func (cfg *apiConfig) requireRole(role string, next http.HandlerFunc) http.HandlerFunc {
+	return cfg.requireLogin(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := principalFromContext(r.Context())
+		user, err := cfg.dbQueries.GetUserByID(r.Context(), p.UserID)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondUnauthorized(w, "user no longer exists")
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't look up user")
+			return
+		}
+		if !hasRole(user.Role, role) {
+			respondWithError(w, http.StatusForbidden, "this endpoint requires the "+role+" role")
+			return
+		}
+		next(w, r)
+	})
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+
+	"github.com/arglp/chirpy/internal/config"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// newMigrationProvider runs the embedded migrations against cfg.db. On
+// Postgres every operation holds an advisory lock for its duration, so
+// instances starting together with AUTO_MIGRATE don't race each other.
+// SQLite's own file locking serialises migrations there.
+func (cfg *apiConfig) newMigrationProvider() (*goose.Provider, error) {
+	if cfg.dbDriver == config.DBDriverSQLite {
+		return goose.NewProvider(goose.DialectSQLite3, cfg.db, cfg.migrations)
+	}
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, err
+	}
+	return goose.NewProvider(goose.DialectPostgres, cfg.db, cfg.migrations, goose.WithSessionLocker(locker))
+}
+
+func (cfg *apiConfig) migrateUp(ctx context.Context) error {
+	provider, err := cfg.newMigrationProvider()
+	if err != nil {
+		return err
+	}
+	results, err := provider.Up(ctx)
+	for _, result := range results {
+		slog.InfoContext(ctx, "applied migration", "version", result.Source.Version, "duration", result.Duration)
+	}
+	return err
+}
+
+func (cfg *apiConfig) commandMigrate(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: chirpy migrate up|down|status|redo")
+	}
+
+	ctx := context.Background()
+	provider, err := cfg.newMigrationProvider()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		results, err := provider.Up(ctx)
+		for _, result := range results {
+			fmt.Println(result)
+		}
+		if err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		return nil
+	case "down":
+		result, err := provider.Down(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Println(result)
+		return nil
+	case "redo":
+		result, err := provider.Down(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Println(result)
+		result, err = provider.UpByOne(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Println(result)
+		return nil
+	case "status":
+		statuses, err := provider.Status(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tSTATE\tAPPLIED AT\tFILE")
+		for _, status := range statuses {
+			appliedAt := "-"
+			if !status.AppliedAt.IsZero() {
+				appliedAt = status.AppliedAt.UTC().Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", status.Source.Version, status.State, appliedAt, status.Source.Path)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
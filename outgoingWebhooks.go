@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/arglp/chirpy/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+const (
+	webhookDeliveryPending   = "pending"
+	webhookDeliverySucceeded = "succeeded"
+	webhookDeliveryFailed    = "failed"
+
+	webhookDeliveryBatch = 20
+)
+
+// emitWebhookEvent queues a delivery of data for every subscription to
+// event held by userID, the user the event is about. Failing to queue is
+// logged rather than failing the request that caused the event.
+func (cfg *apiConfig) emitWebhookEvent(ctx context.Context, userID uuid.UUID, event string, data interface{}) {
+	type envelope struct {
+		Event     string      `json:"event"`
+		CreatedAt time.Time   `json:"created_at"`
+		Data      interface{} `json:"data"`
+	}
+
+	payload, err := json.Marshal(envelope{
+		Event:     event,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "couldn't marshal webhook payload", "event", event, "error", err)
+		return
+	}
+	err = cfg.dbQueries.EnqueueWebhookDeliveries(ctx, database.EnqueueWebhookDeliveriesParams{
+		Event:   event,
+		Payload: payload,
+		UserID:  userID,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "couldn't queue webhook deliveries", "event", event, "error", err)
+	}
+}
+
+// deliverWebhooks sends one batch of due deliveries. Claiming a batch pushes
+// its next attempt a few minutes out, so a crashed worker's deliveries are
+// picked up again and concurrent workers don't send the same delivery.
+func (cfg *apiConfig) deliverWebhooks(ctx context.Context) error {
+	deliveries, err := cfg.dbQueries.ClaimDueWebhookDeliveries(ctx, webhookDeliveryBatch)
+	if err != nil {
+		return err
+	}
+	// A full batch of slow receivers takes far longer than the delivery
+	// interval, so the worker reports in after each delivery rather than
+	// only at the end of the batch. One delivery failing doesn't hold up
+	// the rest; its claim lapses and it is retried.
+	var failed error
+	for _, delivery := range deliveries {
+		err = cfg.attemptWebhookDelivery(ctx, delivery)
+		if err != nil {
+			slog.ErrorContext(ctx, "couldn't record webhook delivery", "delivery_id", delivery.ID, "error", err)
+			failed = errors.Join(failed, err)
+			continue
+		}
+		cfg.heartbeats.progress(workerWebhookDelivery)
+	}
+	return failed
+}
+
+func (cfg *apiConfig) attemptWebhookDelivery(ctx context.Context, delivery database.WebhookDelivery) error {
+	// A subscription that can't be loaded counts as a failed attempt, so
+	// the delivery backs off like any other failure.
+	var status int
+	subscription, sendErr := cfg.dbQueries.GetWebhookSubscription(ctx, delivery.SubscriptionID)
+	if sendErr != nil {
+		sendErr = fmt.Errorf("couldn't load subscription: %w", sendErr)
+	} else {
+		status, sendErr = cfg.webhookSender.Send(ctx, webhooks.Delivery{
+			ID:      delivery.ID.String(),
+			Event:   delivery.Event,
+			URL:     subscription.Url,
+			Secret:  subscription.Secret,
+			Payload: delivery.Payload,
+		})
+	}
+
+	attempt := database.RecordWebhookDeliveryAttemptParams{
+		ID:             delivery.ID,
+		Status:         webhookDeliverySucceeded,
+		NextAttemptAt:  time.Now(),
+		LastStatusCode: sql.NullInt32{Int32: int32(status), Valid: status != 0},
+		DeliveredAt:    sql.NullTime{Time: time.Now(), Valid: true},
+	}
+	if sendErr != nil {
+		attempts := int(delivery.Attempts) + 1
+		attempt.Status = webhookDeliveryPending
+		attempt.NextAttemptAt = time.Now().Add(webhooks.Backoff(attempts))
+		attempt.LastError = sql.NullString{String: sendErr.Error(), Valid: true}
+		attempt.DeliveredAt = sql.NullTime{}
+		if attempts >= webhooks.MaxAttempts {
+			attempt.Status = webhookDeliveryFailed
+		}
+	}
+	return cfg.dbQueries.RecordWebhookDeliveryAttempt(ctx, attempt)
+}
+
+func (cfg *apiConfig) runWebhookDelivery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := cfg.deliverWebhooks(ctx)
+		if err != nil {
+			slog.Error("couldn't deliver webhooks", "error", err)
+		}
+		cfg.heartbeats.beat(workerWebhookDelivery, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
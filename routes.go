@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/arglp/chirpy/internal/auth"
+)
+
+// routes registers every endpoint and wraps them in the request ID, client
+// IP, access log and metrics middleware. cfg.metrics must be set first.
+func (cfg *apiConfig) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	fileServer := http.FileServer(http.Dir("."))
+	fsHandler := cfg.middlewareMetricsInc(http.StripPrefix("/app", fileServer))
+
+	mux.Handle("/app/", fsHandler)
+
+	mux.HandleFunc("GET /api/healthz", cfg.handlerLiveness)
+	mux.HandleFunc("GET /api/livez", cfg.handlerLiveness)
+	mux.HandleFunc("GET /api/readyz", cfg.handlerReadiness)
+
+	mux.HandleFunc("GET /admin/metrics", cfg.requireRole(roleAdmin, cfg.handlerMetrics))
+	mux.HandleFunc("GET /metrics", cfg.requireRole(roleAdmin, cfg.metrics.handler().ServeHTTP))
+	mux.HandleFunc("POST /admin/reset", cfg.requireRole(roleAdmin, cfg.handlerReset))
+	mux.HandleFunc("PUT /admin/users/{userID}/role", cfg.requireRole(roleAdmin, cfg.handlerSetUserRole))
+	mux.HandleFunc("GET /admin/audit", cfg.requireRole(roleAdmin, cfg.handlerListAuditEvents))
+	mux.HandleFunc("GET /admin/webhooks/events", cfg.requireRole(roleAdmin, cfg.handlerListInboundWebhooks))
+	mux.HandleFunc("GET /admin/webhooks/events/{eventID}", cfg.requireRole(roleAdmin, cfg.handlerGetInboundWebhook))
+	mux.HandleFunc("POST /admin/webhooks/events/{eventID}/replay", cfg.requireRole(roleAdmin, cfg.handlerReplayInboundWebhook))
+	mux.HandleFunc("POST /api/users", cfg.handlerUsers)
+	mux.HandleFunc("POST /api/chirps", cfg.requireAuth(auth.ScopeChirpsWrite, cfg.handlerPostChirps))
+	mux.HandleFunc("GET /api/chirps", cfg.optionalAuth(auth.ScopeChirpsRead, cfg.handlerGetChirps))
+	mux.HandleFunc("GET /api/chirps/{chirpID}", cfg.optionalAuth(auth.ScopeChirpsRead, cfg.handlerGetChirpByID))
+	mux.HandleFunc("POST /api/login", cfg.handlerLogin)
+	mux.HandleFunc("POST /api/login/magic", cfg.handlerMagicLink)
+	mux.HandleFunc("POST /api/login/magic/redeem", cfg.handlerRedeemMagicLink)
+	mux.HandleFunc("POST /api/refresh", cfg.handlerRefresh)
+	mux.HandleFunc("POST /api/revoke", cfg.handlerRevoke)
+	mux.HandleFunc("PUT /api/users", cfg.requireLogin(cfg.handlerUpdateUser))
+	mux.HandleFunc("DELETE /api/users/me", cfg.requireLogin(cfg.handlerDeleteAccount))
+	mux.HandleFunc("DELETE /api/users/me/deletion", cfg.requireLogin(cfg.handlerCancelAccountDeletion))
+	mux.HandleFunc("POST /api/users/me/export", cfg.requireLogin(cfg.handlerRequestDataExport))
+	mux.HandleFunc("GET /api/users/me/export", cfg.requireLogin(cfg.handlerGetDataExport))
+	mux.HandleFunc("PUT /api/chirps/{chirpID}", cfg.requireAuth(auth.ScopeChirpsWrite, cfg.handlerUpdateChirp))
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}", cfg.requireAuth(auth.ScopeChirpsWrite, cfg.handlerDeleteChirp))
+	mux.HandleFunc("POST /api/chirps/{chirpID}/report", cfg.requireAuth(auth.ScopeChirpsWrite, cfg.handlerReportChirp))
+	mux.HandleFunc("POST /api/users/{userID}/report", cfg.requireAuth(auth.ScopeChirpsWrite, cfg.handlerReportUser))
+	mux.HandleFunc("PUT /api/users/{userID}/block", cfg.requireAuth(auth.ScopeProfileWrite, cfg.handlerBlockUser))
+	mux.HandleFunc("DELETE /api/users/{userID}/block", cfg.requireAuth(auth.ScopeProfileWrite, cfg.handlerUnblockUser))
+	mux.HandleFunc("GET /api/users/me/blocks", cfg.requireAuth(auth.ScopeProfileRead, cfg.handlerGetBlocks))
+	mux.HandleFunc("PUT /api/users/{userID}/mute", cfg.requireAuth(auth.ScopeProfileWrite, cfg.handlerMuteUser))
+	mux.HandleFunc("DELETE /api/users/{userID}/mute", cfg.requireAuth(auth.ScopeProfileWrite, cfg.handlerUnmuteUser))
+	mux.HandleFunc("GET /api/users/me/mutes", cfg.requireAuth(auth.ScopeProfileRead, cfg.handlerGetMutes))
+	mux.HandleFunc("GET /api/moderation/reports", cfg.requireRole(roleModerator, cfg.handlerListReports))
+	mux.HandleFunc("GET /api/moderation/reports/{reportID}", cfg.requireRole(roleModerator, cfg.handlerGetReport))
+	mux.HandleFunc("POST /api/moderation/reports/{reportID}/claim", cfg.requireRole(roleModerator, cfg.handlerClaimReport))
+	mux.HandleFunc("POST /api/moderation/reports/{reportID}/unclaim", cfg.requireRole(roleModerator, cfg.handlerUnclaimReport))
+	mux.HandleFunc("POST /api/moderation/reports/{reportID}/resolve", cfg.requireRole(roleModerator, cfg.handlerResolveReport))
+	mux.HandleFunc("PUT /api/moderation/users/{userID}/suspension", cfg.requireRole(roleModerator, cfg.handlerSuspendUser))
+	mux.HandleFunc("DELETE /api/moderation/users/{userID}/suspension", cfg.requireRole(roleModerator, cfg.handlerUnsuspendUser))
+	mux.HandleFunc("PUT /api/moderation/users/{userID}/shadowban", cfg.requireRole(roleModerator, cfg.handlerShadowbanUser))
+	mux.HandleFunc("POST /api/polka/webhooks", cfg.handlerPolkaWebhook)
+	mux.HandleFunc("POST /api/webhooks", cfg.requireAuth(auth.ScopeWebhooksManage, cfg.handlerCreateWebhook))
+	mux.HandleFunc("GET /api/webhooks", cfg.requireAuth(auth.ScopeWebhooksManage, cfg.handlerGetWebhooks))
+	mux.HandleFunc("DELETE /api/webhooks/{webhookID}", cfg.requireAuth(auth.ScopeWebhooksManage, cfg.handlerDeleteWebhook))
+	mux.HandleFunc("GET /api/webhooks/{webhookID}/deliveries", cfg.requireAuth(auth.ScopeWebhooksManage, cfg.handlerGetWebhookDeliveries))
+	mux.HandleFunc("POST /api/webhooks/{webhookID}/deliveries/{deliveryID}/redeliver", cfg.requireAuth(auth.ScopeWebhooksManage, cfg.handlerRedeliverWebhook))
+	mux.HandleFunc("GET /api/subscription", cfg.requireAuth("", cfg.handlerGetSubscription))
+	mux.HandleFunc("POST /api/tokens", cfg.requireLogin(cfg.handlerCreateToken))
+	mux.HandleFunc("GET /api/tokens", cfg.requireLogin(cfg.handlerGetTokens))
+	mux.HandleFunc("DELETE /api/tokens/{tokenID}", cfg.requireLogin(cfg.handlerRevokeToken))
+	mux.HandleFunc("POST /api/oauth/clients", cfg.requireLogin(cfg.handlerCreateOAuthClient))
+	mux.HandleFunc("GET /api/oauth/authorize", cfg.handlerGetAuthorize)
+	mux.HandleFunc("POST /api/oauth/authorize", cfg.requireLogin(cfg.handlerPostAuthorize))
+	mux.HandleFunc("POST /api/oauth/token", cfg.handlerOAuthToken)
+	mux.HandleFunc("POST /api/oauth/revoke", cfg.handlerOAuthRevoke)
+	mux.HandleFunc("POST /api/oauth/introspect", cfg.handlerOAuthIntrospect)
+
+	return middlewareRequestID(middlewareClientIP(middlewareAccessLog(cfg.metrics.middleware(mux))))
+}
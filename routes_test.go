@@ -0,0 +1,1480 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/arglp/chirpy/internal/auth"
+	"github.com/arglp/chirpy/internal/config"
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/arglp/chirpy/internal/mailer"
+	"github.com/arglp/chirpy/internal/memstore"
+	"github.com/arglp/chirpy/internal/sqlitestore"
+	"github.com/arglp/chirpy/internal/webhooks"
+	"github.com/arglp/chirpy/sql/schema"
+	sqliteschema "github.com/arglp/chirpy/sql/sqlite/schema"
+	"github.com/google/uuid"
+)
+
+const (
+	testSecret   = "test-secret-that-is-long-enough-for-hs256"
+	testPolkaKey = "test-polka-key"
+)
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+type recordingMailer struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+	// wait blocks until mail sent in the background has gone out.
+	wait func()
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+func (m *recordingMailer) last(t *testing.T) mailer.Message {
+	t.Helper()
+	if m.wait != nil {
+		m.wait()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.messages) == 0 {
+		t.Fatal("no mail was sent")
+	}
+	return m.messages[len(m.messages)-1]
+}
+
+type testServer struct {
+	t      *testing.T
+	url    string
+	cfg    *apiConfig
+	store  *memstore.Store
+	mailer *recordingMailer
+}
+
+// newTestServer runs every route against an in-memory store on the dev
+// platform, with cheap password hashing to keep the suite fast.
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	store := memstore.New()
+	mail := &recordingMailer{}
+	cfg := &apiConfig{
+		dbQueries:       store,
+		platform:        "dev",
+		secret:          testSecret,
+		polkaKey:        testPolkaKey,
+		passwordParams:  &argon2id.Params{Memory: 8 * 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+		mailer:          mail,
+		magicLinkURL:    "http://localhost:8080/app/login",
+		webhookSender:   webhooks.Sender{Client: &http.Client{Timeout: 5 * time.Second}},
+		migrations:      schema.FS,
+		heartbeats:      newWorkerHeartbeats(),
+		accessTokenTTL:  time.Hour,
+		refreshTokenTTL: 24 * time.Hour,
+		magicLinkTTL:    15 * time.Minute,
+
+		accountDeletionGracePeriod: 30 * 24 * time.Hour,
+		dataExportTTL:              24 * time.Hour,
+	}
+	cfg.metrics = newServerMetrics(nil)
+	mail.wait = cfg.background.Wait
+
+	srv := httptest.NewServer(cfg.routes())
+	t.Cleanup(srv.Close)
+	return &testServer{t: t, url: srv.URL, cfg: cfg, store: store, mailer: mail}
+}
+
+// request sends body as JSON, or as-is when it is already a string, and
+// authenticates with token when it is not empty.
+func (ts *testServer) request(method, path, token string, body any) *http.Response {
+	ts.t.Helper()
+
+	var reader io.Reader
+	contentType := "application/json"
+	switch b := body.(type) {
+	case nil:
+	case url.Values:
+		reader = strings.NewReader(b.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	case string:
+		reader = strings.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			ts.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, ts.url+path, reader)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	if reader != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	ts.t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func expectStatus(t *testing.T, resp *http.Response, want int) {
+	t.Helper()
+	if resp.StatusCode != want {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("%s %s: status = %d, want %d; body: %s", resp.Request.Method, resp.Request.URL.Path, resp.StatusCode, want, body)
+	}
+}
+
+func decode[T any](t *testing.T, resp *http.Response) T {
+	t.Helper()
+	var v T
+	err := json.NewDecoder(resp.Body).Decode(&v)
+	if err != nil {
+		t.Fatalf("%s %s: couldn't decode response: %v", resp.Request.Method, resp.Request.URL.Path, err)
+	}
+	return v
+}
+
+func (ts *testServer) createUser(email, password string) User {
+	ts.t.Helper()
+	resp := ts.request("POST", "/api/users", "", map[string]string{"email": email, "password": password})
+	expectStatus(ts.t, resp, 201)
+	return decode[User](ts.t, resp)
+}
+
+func (ts *testServer) login(email, password string) User {
+	ts.t.Helper()
+	resp := ts.request("POST", "/api/login", "", map[string]string{"email": email, "password": password})
+	expectStatus(ts.t, resp, 200)
+	return decode[User](ts.t, resp)
+}
+
+// signUp creates a user and returns its logged-in session.
+func (ts *testServer) signUp(email string) User {
+	ts.t.Helper()
+	ts.createUser(email, "correct horse")
+	return ts.login(email, "correct horse")
+}
+
+// signUpAdmin creates a user, promotes it to admin and logs it in.
+func (ts *testServer) signUpAdmin(email string) User {
+	ts.t.Helper()
+	session := ts.signUp(email)
+	err := ts.cfg.bootstrapAdmin(context.Background(), email)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	return session
+}
+
+func (ts *testServer) postChirp(token, body string) Chirp {
+	ts.t.Helper()
+	resp := ts.request("POST", "/api/chirps", token, map[string]string{"body": body})
+	expectStatus(ts.t, resp, 201)
+	return decode[Chirp](ts.t, resp)
+}
+
+func (ts *testServer) polka(event map[string]any) *http.Response {
+	ts.t.Helper()
+	data, err := json.Marshal(event)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	req, err := http.NewRequest("POST", ts.url+"/api/polka/webhooks", bytes.NewReader(data))
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "ApiKey "+testPolkaKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	ts.t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// TestWithTx runs transactions on SQLite with the single connection the
+// server uses there, so a query made outside the transaction would hang.
+func TestWithTx(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlitestore.Open("sqlite:" + filepath.Join(t.TempDir(), "chirpy.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	cfg := &apiConfig{db: db, dbDriver: config.DBDriverSQLite, dbQueries: sqlitestore.New(db), migrations: sqliteschema.FS}
+	err = cfg.migrateUp(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errAbort := errors.New("abort")
+	err = cfg.withTx(ctx, func(q database.Querier) error {
+		_, err := q.CreateUser(ctx, database.CreateUserParams{Email: "rolled@example.com", HashedPassword: "hash"})
+		if err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("withTx error = %v, want the one returned by fn", err)
+	}
+	_, err = cfg.dbQueries.GetUser(ctx, "rolled@example.com")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUser after rollback error = %v, want sql.ErrNoRows", err)
+	}
+
+	err = cfg.withTx(ctx, func(q database.Querier) error {
+		_, err := q.CreateUser(ctx, database.CreateUserParams{Email: "kept@example.com", HashedPassword: "hash"})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cfg.dbQueries.GetUser(ctx, "kept@example.com")
+	if err != nil {
+		t.Errorf("GetUser after commit error = %v", err)
+	}
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	ts := newTestServer(t)
+
+	expectStatus(t, ts.request("GET", "/api/healthz", "", nil), 200)
+	expectStatus(t, ts.request("GET", "/api/livez", "", nil), 200)
+
+	// The in-memory store has no database to ping or migrate.
+	resp := ts.request("GET", "/api/readyz", "", nil)
+	expectStatus(t, resp, 503)
+	readiness := decode[Readiness](t, resp)
+	if readiness.Status != "unavailable" {
+		t.Errorf("status = %q, want unavailable", readiness.Status)
+	}
+	checks := map[string]HealthCheck{}
+	for _, check := range readiness.Checks {
+		checks[check.Name] = check
+	}
+	if checks["database"].Status != "fail" || checks["migrations"].Status != "fail" {
+		t.Errorf("checks = %+v, want database and migrations to fail", readiness.Checks)
+	}
+}
+
+func TestMetricsAndFileServer(t *testing.T) {
+	ts := newTestServer(t)
+
+	admin := ts.signUpAdmin("admin@example.com")
+	expectStatus(t, ts.request("GET", "/app/", "", nil), 200)
+
+	resp := ts.request("GET", "/admin/metrics", admin.Token, nil)
+	expectStatus(t, resp, 200)
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "visited 1 times") {
+		t.Errorf("admin metrics = %q, want one visit", body)
+	}
+
+	expectStatus(t, ts.request("GET", "/metrics", "", nil), 401)
+	user := ts.signUp("user@example.com")
+	expectStatus(t, ts.request("GET", "/metrics", user.Token, nil), 403)
+	resp = ts.request("GET", "/metrics", admin.Token, nil)
+	expectStatus(t, resp, 200)
+	body, _ = io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `chirpy_http_requests_total{code="200",method="GET",route="/app/"}`) {
+		t.Errorf("/metrics is missing the request counter for /app/")
+	}
+}
+
+func TestUsersAndLogin(t *testing.T) {
+	ts := newTestServer(t)
+
+	user := ts.createUser("saul@example.com", "correct horse")
+	if user.Email != "saul@example.com" || user.Entitlements.MaxChirpLength != 140 {
+		t.Errorf("created user = %+v", user)
+	}
+	expectStatus(t, ts.request("POST", "/api/users", "", map[string]string{"email": "saul@example.com", "password": "x"}), 400)
+
+	expectStatus(t, ts.request("POST", "/api/login", "", map[string]string{"email": "saul@example.com", "password": "wrong"}), 401)
+	expectStatus(t, ts.request("POST", "/api/login", "", map[string]string{"email": "nobody@example.com", "password": "x"}), 401)
+	session := ts.login("saul@example.com", "correct horse")
+	if session.Token == "" || session.RefreshToken == "" {
+		t.Fatalf("login returned no tokens: %+v", session)
+	}
+
+	update := map[string]string{"email": "jimmy@example.com", "password": "battery staple"}
+	expectStatus(t, ts.request("PUT", "/api/users", "", update), 401)
+	// Changing credentials takes a login, not just a scoped token.
+	resp := ts.request("POST", "/api/tokens", session.Token, map[string]any{"name": "profile", "scopes": []string{auth.ScopeProfileWrite}})
+	expectStatus(t, resp, 201)
+	pat := decode[PersonalAccessToken](t, resp)
+	expectStatus(t, ts.request("PUT", "/api/users", pat.Token, update), 403)
+	resp = ts.request("PUT", "/api/users", session.Token, update)
+	expectStatus(t, resp, 200)
+	if updated := decode[User](t, resp); updated.Email != "jimmy@example.com" {
+		t.Errorf("updated email = %q", updated.Email)
+	}
+	ts.login("jimmy@example.com", "battery staple")
+}
+
+func TestRefreshAndRevoke(t *testing.T) {
+	ts := newTestServer(t)
+	session := ts.signUp("kim@example.com")
+
+	resp := ts.request("POST", "/api/refresh", session.RefreshToken, nil)
+	expectStatus(t, resp, 200)
+	refreshed := decode[struct {
+		Token string `json:"token"`
+	}](t, resp)
+	expectStatus(t, ts.request("GET", "/api/subscription", refreshed.Token, nil), 404)
+
+	expectStatus(t, ts.request("POST", "/api/refresh", "not-a-token", nil), 401)
+	expectStatus(t, ts.request("POST", "/api/revoke", session.RefreshToken, nil), 204)
+	resp = ts.request("POST", "/api/refresh", session.RefreshToken, nil)
+	expectStatus(t, resp, 401)
+	if !strings.Contains(resp.Header.Get("WWW-Authenticate"), "revoked") {
+		t.Errorf("WWW-Authenticate = %q, want a revocation description", resp.Header.Get("WWW-Authenticate"))
+	}
+}
+
+func TestChirps(t *testing.T) {
+	ts := newTestServer(t)
+	author := ts.signUp("author@example.com")
+	other := ts.signUp("other@example.com")
+
+	expectStatus(t, ts.request("POST", "/api/chirps", "", map[string]string{"body": "hi"}), 401)
+	expectStatus(t, ts.request("POST", "/api/chirps", author.Token, map[string]string{"body": strings.Repeat("a", 141)}), 400)
+
+	chirp := ts.postChirp(author.Token, "I had a kerfuffle today")
+	if chirp.Body != "I had a **** today" || chirp.UserID != author.ID {
+		t.Errorf("chirp = %+v", chirp)
+	}
+	ts.postChirp(other.Token, "second")
+
+	resp := ts.request("GET", "/api/chirps", "", nil)
+	expectStatus(t, resp, 200)
+	if chirps := decode[[]Chirp](t, resp); len(chirps) != 2 || chirps[0].ID != chirp.ID {
+		t.Errorf("chirps = %+v, want two in creation order", chirps)
+	}
+	expectStatus(t, ts.request("GET", "/api/chirps/"+chirp.ID.String(), "", nil), 200)
+	expectStatus(t, ts.request("GET", "/api/chirps/not-a-uuid", "", nil), 400)
+	expectStatus(t, ts.request("GET", "/api/chirps/"+uuid.NewString(), "", nil), 404)
+	expectStatus(t, ts.request("GET", "/api/chirps", "garbage", nil), 401)
+
+	// Editing is a Chirpy Red entitlement.
+	edit := map[string]string{"body": "edited"}
+	expectStatus(t, ts.request("PUT", "/api/chirps/"+chirp.ID.String(), author.Token, edit), 403)
+	expectStatus(t, ts.polka(map[string]any{"id": "evt_red", "event": "user.upgraded", "data": map[string]any{"user_id": author.ID}}), 204)
+	expectStatus(t, ts.request("PUT", "/api/chirps/"+chirp.ID.String(), other.Token, edit), 403)
+	resp = ts.request("PUT", "/api/chirps/"+chirp.ID.String(), author.Token, edit)
+	expectStatus(t, resp, 200)
+	if edited := decode[Chirp](t, resp); edited.Body != "edited" {
+		t.Errorf("edited body = %q", edited.Body)
+	}
+
+	expectStatus(t, ts.request("DELETE", "/api/chirps/"+chirp.ID.String(), other.Token, nil), 403)
+	expectStatus(t, ts.request("DELETE", "/api/chirps/"+chirp.ID.String(), author.Token, nil), 204)
+	expectStatus(t, ts.request("GET", "/api/chirps/"+chirp.ID.String(), "", nil), 404)
+}
+
+func TestPersonalAccessTokens(t *testing.T) {
+	ts := newTestServer(t)
+	session := ts.signUp("pat@example.com")
+
+	create := map[string]any{"name": "reader", "scopes": []string{auth.ScopeChirpsRead}}
+	expectStatus(t, ts.request("POST", "/api/tokens", "", create), 401)
+	expectStatus(t, ts.request("POST", "/api/tokens", session.Token, map[string]any{"name": "bad", "scopes": []string{"everything"}}), 400)
+	resp := ts.request("POST", "/api/tokens", session.Token, create)
+	expectStatus(t, resp, 201)
+	pat := decode[PersonalAccessToken](t, resp)
+	if !auth.IsPersonalAccessToken(pat.Token) {
+		t.Fatalf("token = %q, want a personal access token", pat.Token)
+	}
+
+	expectStatus(t, ts.request("GET", "/api/chirps", pat.Token, nil), 200)
+	expectStatus(t, ts.request("POST", "/api/chirps", pat.Token, map[string]string{"body": "hi"}), 403)
+	// Tokens can't mint more tokens.
+	expectStatus(t, ts.request("POST", "/api/tokens", pat.Token, create), 403)
+
+	resp = ts.request("GET", "/api/tokens", session.Token, nil)
+	expectStatus(t, resp, 200)
+	if tokens := decode[[]PersonalAccessToken](t, resp); len(tokens) != 1 || tokens[0].Token != "" || tokens[0].LastUsedAt == nil {
+		t.Errorf("tokens = %+v, want one used token without its secret", tokens)
+	}
+
+	expectStatus(t, ts.request("DELETE", "/api/tokens/"+pat.ID.String(), session.Token, nil), 204)
+	expectStatus(t, ts.request("DELETE", "/api/tokens/"+pat.ID.String(), session.Token, nil), 404)
+	expectStatus(t, ts.request("GET", "/api/chirps", pat.Token, nil), 401)
+}
+
+var magicTokenPattern = regexp.MustCompile(`token=([^\s&]+)`)
+
+func TestMagicLink(t *testing.T) {
+	ts := newTestServer(t)
+	ts.createUser("magic@example.com", "correct horse")
+
+	expectStatus(t, ts.request("POST", "/api/login/magic", "", map[string]string{"email": "nobody@example.com"}), 202)
+	expectStatus(t, ts.request("POST", "/api/login/magic", "", map[string]string{"email": "magic@example.com"}), 202)
+
+	msg := ts.mailer.last(t)
+	match := magicTokenPattern.FindStringSubmatch(msg.Body)
+	if msg.To != "magic@example.com" || match == nil || !strings.Contains(msg.Body, "expires in 15 minutes") {
+		t.Fatalf("mail = %+v, want a login link for magic@example.com", msg)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := ts.request("POST", "/api/login/magic/redeem", "", map[string]string{"token": token})
+	expectStatus(t, resp, 200)
+	if session := decode[User](t, resp); session.Token == "" {
+		t.Error("redeem returned no access token")
+	}
+	expectStatus(t, ts.request("POST", "/api/login/magic/redeem", "", map[string]string{"token": token}), 401)
+}
+
+func TestOAuthAuthorizationCodeFlow(t *testing.T) {
+	ts := newTestServer(t)
+	session := ts.signUp("owner@example.com")
+	redirectURI := "http://localhost:3000/callback"
+
+	newClient := map[string]any{
+		"name":          "Chirp reader",
+		"redirect_uris": []string{redirectURI},
+		"scopes":        []string{auth.ScopeChirpsRead},
+	}
+	expectStatus(t, ts.request("POST", "/api/oauth/clients", "", newClient), 401)
+	resp := ts.request("POST", "/api/oauth/clients", session.Token, newClient)
+	expectStatus(t, resp, 201)
+	client := decode[OAuthClient](t, resp)
+
+	verifier := strings.Repeat("v", 43)
+	sum := sha256.Sum256([]byte(verifier))
+	authorize := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {client.ID.String()},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {auth.ScopeChirpsRead},
+		"state":                 {"xyz"},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(sum[:])},
+		"code_challenge_method": {"S256"},
+	}
+	expectStatus(t, ts.request("GET", "/api/oauth/authorize?"+authorize.Encode(), "", nil), 200)
+	authorize.Set("scope", auth.ScopeChirpsWrite)
+	expectStatus(t, ts.request("GET", "/api/oauth/authorize?"+authorize.Encode(), "", nil), 400)
+	authorize.Set("scope", auth.ScopeChirpsRead)
+
+	consent := map[string]any{"approve": true}
+	for key := range authorize {
+		consent[key] = authorize.Get(key)
+	}
+	resp = ts.request("POST", "/api/oauth/authorize", session.Token, consent)
+	expectStatus(t, resp, 200)
+	redirect, err := url.Parse(decode[struct {
+		RedirectURI string `json:"redirect_uri"`
+	}](t, resp).RedirectURI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redirect.Query().Get("state") != "xyz" {
+		t.Errorf("redirect = %s, want state to round-trip", redirect)
+	}
+
+	exchange := url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {client.ID.String()},
+		"code":          {redirect.Query().Get("code")},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {strings.Repeat("w", 43)},
+	}
+	expectStatus(t, ts.request("POST", "/api/oauth/token", "", exchange), 400)
+
+	// A failed exchange burns the code, so authorize again.
+	resp = ts.request("POST", "/api/oauth/authorize", session.Token, consent)
+	expectStatus(t, resp, 200)
+	redirect, _ = url.Parse(decode[struct {
+		RedirectURI string `json:"redirect_uri"`
+	}](t, resp).RedirectURI)
+	exchange.Set("code", redirect.Query().Get("code"))
+	exchange.Set("code_verifier", verifier)
+	resp = ts.request("POST", "/api/oauth/token", "", exchange)
+	expectStatus(t, resp, 200)
+	type tokenResponse struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		Scope        string `json:"scope"`
+	}
+	tokens := decode[tokenResponse](t, resp)
+
+	expectStatus(t, ts.request("GET", "/api/chirps", tokens.AccessToken, nil), 200)
+	expectStatus(t, ts.request("POST", "/api/chirps", tokens.AccessToken, map[string]string{"body": "hi"}), 403)
+	expectStatus(t, ts.request("POST", "/api/tokens", tokens.AccessToken, map[string]any{"name": "x", "scopes": []string{auth.ScopeChirpsRead}}), 403)
+	expectStatus(t, ts.request("POST", "/api/refresh", tokens.RefreshToken, nil), 401)
+
+	resp = ts.request("POST", "/api/oauth/introspect", "", url.Values{"client_id": {client.ID.String()}, "token": {tokens.AccessToken}})
+	expectStatus(t, resp, 200)
+	if introspection := decode[struct {
+		Active bool   `json:"active"`
+		Sub    string `json:"sub"`
+	}](t, resp); !introspection.Active || introspection.Sub != session.ID.String() {
+		t.Errorf("introspection = %+v, want an active token for the owner", introspection)
+	}
+
+	resp = ts.request("POST", "/api/oauth/token", "", url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {client.ID.String()},
+		"refresh_token": {tokens.RefreshToken},
+	})
+	expectStatus(t, resp, 200)
+	rotated := decode[tokenResponse](t, resp)
+	if rotated.RefreshToken == tokens.RefreshToken {
+		t.Error("refresh token was not rotated")
+	}
+
+	expectStatus(t, ts.request("POST", "/api/oauth/revoke", "", url.Values{"client_id": {client.ID.String()}, "token": {rotated.RefreshToken}}), 200)
+	resp = ts.request("POST", "/api/oauth/introspect", "", url.Values{"client_id": {client.ID.String()}, "token": {rotated.RefreshToken}})
+	expectStatus(t, resp, 200)
+	if decode[struct {
+		Active bool `json:"active"`
+	}](t, resp).Active {
+		t.Error("revoked refresh token is still active")
+	}
+	expectStatus(t, ts.request("POST", "/api/oauth/token", "", url.Values{"grant_type": {"password"}, "client_id": {uuid.NewString()}}), 401)
+}
+
+func TestPolkaWebhooksAndSubscription(t *testing.T) {
+	ts := newTestServer(t)
+	session := ts.signUp("red@example.com")
+	upgrade := map[string]any{
+		"id":    "evt_1",
+		"event": "user.upgraded",
+		"data":  map[string]any{"user_id": session.ID, "period_end": time.Now().Add(30 * 24 * time.Hour)},
+	}
+
+	req, _ := http.NewRequest("POST", ts.url+"/api/polka/webhooks", strings.NewReader("{}"))
+	req.Header.Set("Authorization", "ApiKey wrong")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	expectStatus(t, resp, 401)
+
+	expectStatus(t, ts.polka(upgrade), 204)
+	expectStatus(t, ts.polka(upgrade), 204)
+	expectStatus(t, ts.polka(map[string]any{"id": "evt_unknown_user", "event": "user.upgraded", "data": map[string]any{"user_id": uuid.New()}}), 404)
+	expectStatus(t, ts.polka(map[string]any{"event": "user.signed_up"}), 204)
+	// Without an id a retried delivery couldn't be told apart from a new event.
+	expectStatus(t, ts.polka(map[string]any{"event": "user.renewed", "data": map[string]any{"user_id": session.ID}}), 400)
+
+	expectStatus(t, ts.request("GET", "/api/subscription", "", nil), 401)
+	resp = ts.request("GET", "/api/subscription", session.Token, nil)
+	expectStatus(t, resp, 200)
+	subscription := decode[Subscription](t, resp)
+	if subscription.Status != subscriptionActive || len(subscription.History) != 1 {
+		t.Errorf("subscription = %+v, want one active upgrade despite the duplicate delivery", subscription)
+	}
+	if user := ts.login("red@example.com", "correct horse"); !user.IsChirpyRed {
+		t.Error("user was not upgraded")
+	}
+
+	// A cancel arriving after a downgrade leaves the subscription ended.
+	expectStatus(t, ts.polka(map[string]any{"id": "evt_2", "event": "user.downgraded", "data": map[string]any{"user_id": session.ID}}), 204)
+	expectStatus(t, ts.polka(map[string]any{"id": "evt_3", "event": "user.cancelled", "data": map[string]any{"user_id": session.ID}}), 204)
+	resp = ts.request("GET", "/api/subscription", session.Token, nil)
+	expectStatus(t, resp, 200)
+	if subscription := decode[Subscription](t, resp); subscription.Status != subscriptionExpired {
+		t.Errorf("status after a late cancel = %q, want %q", subscription.Status, subscriptionExpired)
+	}
+	if user := ts.login("red@example.com", "correct horse"); user.IsChirpyRed {
+		t.Error("a late cancel restored Chirpy Red")
+	}
+}
+
+func TestSubscriptionExpiry(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+	session := ts.signUp("lapsed@example.com")
+	paidUntil := time.Now().Add(60 * 24 * time.Hour)
+	expectStatus(t, ts.polka(map[string]any{"id": "evt_long", "event": "user.upgraded", "data": map[string]any{"user_id": session.ID, "period_end": paidUntil}}), 204)
+	expectStatus(t, ts.polka(map[string]any{"id": "evt_again", "event": "user.upgraded", "data": map[string]any{"user_id": session.ID}}), 204)
+	resp := ts.request("GET", "/api/subscription", session.Token, nil)
+	expectStatus(t, resp, 200)
+	if subscription := decode[Subscription](t, resp); subscription.CurrentPeriodEnd.Before(paidUntil.Add(-time.Second)) {
+		t.Errorf("period end after a second upgrade = %s, want %s kept", subscription.CurrentPeriodEnd, paidUntil)
+	}
+
+	// A subscription that is active again keeps Chirpy Red.
+	revoked, err := ts.store.RevokeExpiredChirpyRed(ctx, session.ID)
+	if err != nil || revoked != 0 {
+		t.Errorf("RevokeExpiredChirpyRed() on an active subscription = %d, %v, want 0", revoked, err)
+	}
+
+	_, err = ts.store.UpsertSubscription(ctx, database.UpsertSubscriptionParams{
+		UserID:           session.ID,
+		Plan:             planChirpyRed,
+		Status:           subscriptionActive,
+		CurrentPeriodEnd: time.Now().Add(-time.Minute),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ts.cfg.expireSubscriptions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp = ts.request("GET", "/api/subscription", session.Token, nil)
+	expectStatus(t, resp, 200)
+	if subscription := decode[Subscription](t, resp); subscription.Status != subscriptionExpired {
+		t.Errorf("status after expiry = %q, want %q", subscription.Status, subscriptionExpired)
+	}
+	if user := ts.login("lapsed@example.com", "correct horse"); user.IsChirpyRed {
+		t.Error("Chirpy Red survived an expired subscription")
+	}
+}
+
+func TestOutgoingWebhooks(t *testing.T) {
+	ts := newTestServer(t)
+	session := ts.signUp("dev@example.com")
+
+	received := make(chan *http.Request, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r
+	}))
+	defer receiver.Close()
+
+	create := map[string]any{"url": receiver.URL, "events": []string{webhooks.EventChirpCreated}}
+	expectStatus(t, ts.request("POST", "/api/webhooks", "", create), 401)
+	expectStatus(t, ts.request("POST", "/api/webhooks", session.Token, map[string]any{"url": receiver.URL, "events": []string{"user.followed"}}), 400)
+	resp := ts.request("POST", "/api/webhooks", session.Token, create)
+	expectStatus(t, resp, 201)
+	subscription := decode[WebhookSubscription](t, resp)
+	if subscription.Secret == "" {
+		t.Fatal("webhook secret was not returned on creation")
+	}
+
+	resp = ts.request("GET", "/api/webhooks", session.Token, nil)
+	expectStatus(t, resp, 200)
+	if list := decode[[]WebhookSubscription](t, resp); len(list) != 1 || list[0].Secret != "" {
+		t.Errorf("webhooks = %+v, want one without its secret", list)
+	}
+
+	ts.postChirp(session.Token, "hello hooks")
+	err := ts.cfg.deliverWebhooks(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case r := <-received:
+		if r.Header.Get("X-Chirpy-Event") != webhooks.EventChirpCreated || r.Header.Get("X-Chirpy-Signature") == "" {
+			t.Errorf("delivery headers = %v", r.Header)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+
+	stranger := ts.signUp("stranger@example.com")
+	ts.postChirp(stranger.Token, "not yours to watch")
+
+	deliveriesPath := "/api/webhooks/" + subscription.ID.String() + "/deliveries"
+	resp = ts.request("GET", deliveriesPath, session.Token, nil)
+	expectStatus(t, resp, 200)
+	deliveries := decode[[]WebhookDelivery](t, resp)
+	if len(deliveries) != 1 || deliveries[0].Status != webhookDeliverySucceeded {
+		t.Fatalf("deliveries = %+v, want only the one for the caller's chirp, delivered", deliveries)
+	}
+
+	resp = ts.request("POST", deliveriesPath+"/"+deliveries[0].ID.String()+"/redeliver", session.Token, nil)
+	expectStatus(t, resp, 202)
+	if redelivery := decode[WebhookDelivery](t, resp); redelivery.Status != webhookDeliveryPending {
+		t.Errorf("redelivery status = %q, want pending", redelivery.Status)
+	}
+
+	expectStatus(t, ts.request("GET", deliveriesPath, stranger.Token, nil), 404)
+	expectStatus(t, ts.request("DELETE", "/api/webhooks/"+subscription.ID.String(), stranger.Token, nil), 404)
+	expectStatus(t, ts.request("DELETE", "/api/webhooks/"+subscription.ID.String(), session.Token, nil), 204)
+	expectStatus(t, ts.request("GET", deliveriesPath, session.Token, nil), 404)
+}
+
+// brokenSubscriptionQuerier fails to load one webhook subscription.
+type brokenSubscriptionQuerier struct {
+	database.Querier
+	broken uuid.UUID
+}
+
+func (q brokenSubscriptionQuerier) GetWebhookSubscription(ctx context.Context, id uuid.UUID) (database.WebhookSubscription, error) {
+	if id == q.broken {
+		return database.WebhookSubscription{}, errors.New("connection reset")
+	}
+	return q.Querier.GetWebhookSubscription(ctx, id)
+}
+
+func TestWebhookDeliveryFailureDoesNotStopBatch(t *testing.T) {
+	ts := newTestServer(t)
+	received := make(chan *http.Request, 2)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r
+	}))
+	defer receiver.Close()
+
+	var subscriptions []WebhookSubscription
+	var sessions []User
+	for _, email := range []string{"broken@example.com", "working@example.com"} {
+		session := ts.signUp(email)
+		resp := ts.request("POST", "/api/webhooks", session.Token, map[string]any{"url": receiver.URL, "events": []string{webhooks.EventChirpCreated}})
+		expectStatus(t, resp, 201)
+		subscriptions = append(subscriptions, decode[WebhookSubscription](t, resp))
+		sessions = append(sessions, session)
+		ts.postChirp(session.Token, "hello from "+email)
+	}
+
+	ts.cfg.dbQueries = brokenSubscriptionQuerier{Querier: ts.store, broken: subscriptions[0].ID}
+	if err := ts.cfg.deliverWebhooks(context.Background()); err != nil {
+		t.Fatalf("deliverWebhooks() error = %v, want the failure recorded on its delivery", err)
+	}
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the working webhook was not delivered")
+	}
+
+	ts.cfg.dbQueries = ts.store
+	resp := ts.request("GET", "/api/webhooks/"+subscriptions[0].ID.String()+"/deliveries", sessions[0].Token, nil)
+	expectStatus(t, resp, 200)
+	deliveries := decode[[]WebhookDelivery](t, resp)
+	if len(deliveries) != 1 || deliveries[0].Status != webhookDeliveryPending || deliveries[0].Attempts != 1 || deliveries[0].LastError == nil {
+		t.Errorf("deliveries = %+v, want one pending retry with the error recorded", deliveries)
+	}
+}
+
+func TestAdminInboundWebhooks(t *testing.T) {
+	ts := newTestServer(t)
+	session := ts.signUp("replay@example.com")
+	admin := ts.signUpAdmin("admin@example.com")
+
+	event := map[string]any{"id": "evt_replay", "event": "user.upgraded", "data": map[string]any{"user_id": session.ID}}
+	expectStatus(t, ts.polka(event), 204)
+	expectStatus(t, ts.polka(map[string]any{"id": "evt_replay_unknown", "event": "user.upgraded", "data": map[string]any{"user_id": uuid.New()}}), 404)
+
+	resp := ts.request("GET", "/admin/webhooks/events", admin.Token, nil)
+	expectStatus(t, resp, 200)
+	if events := decode[[]InboundWebhook](t, resp); len(events) != 2 {
+		t.Errorf("got %d inbound webhooks, want 2", len(events))
+	}
+	expectStatus(t, ts.request("GET", "/admin/webhooks/events?limit=-1", admin.Token, nil), 400)
+
+	resp = ts.request("GET", "/admin/webhooks/events?outcome="+webhookOutcomeFailed, admin.Token, nil)
+	expectStatus(t, resp, 200)
+	failed := decode[[]InboundWebhook](t, resp)
+	if len(failed) != 1 {
+		t.Fatalf("got %d failed webhooks, want 1", len(failed))
+	}
+
+	expectStatus(t, ts.request("GET", "/admin/webhooks/events/"+failed[0].ID.String(), admin.Token, nil), 200)
+	expectStatus(t, ts.request("GET", "/admin/webhooks/events/"+uuid.NewString(), admin.Token, nil), 404)
+	resp = ts.request("POST", "/admin/webhooks/events/"+failed[0].ID.String()+"/replay", admin.Token, nil)
+	expectStatus(t, resp, 200)
+	if replay := decode[InboundWebhook](t, resp); replay.ReplayOf == nil || *replay.ReplayOf != failed[0].ID {
+		t.Errorf("replay = %+v, want it linked to the original", replay)
+	}
+	resp = ts.request("GET", "/admin/audit?target_id="+failed[0].ID.String(), admin.Token, nil)
+	expectStatus(t, resp, 200)
+	if events := decode[[]AuditEvent](t, resp); len(events) != 1 || events[0].Action != auditWebhookReplayed || *events[0].ActorID != admin.ID {
+		t.Errorf("audit events for the webhook = %+v, want the replay", events)
+	}
+
+	// Events are logged before they are authenticated, so a forged one must
+	// not become replayable.
+	expectStatus(t, ts.request("POST", "/api/polka/webhooks", "", event), 401)
+	resp = ts.request("GET", "/admin/webhooks/events?outcome="+webhookOutcomeRejected, admin.Token, nil)
+	expectStatus(t, resp, 200)
+	rejected := decode[[]InboundWebhook](t, resp)
+	if len(rejected) != 1 {
+		t.Fatalf("got %d rejected webhooks, want 1", len(rejected))
+	}
+	expectStatus(t, ts.request("POST", "/admin/webhooks/events/"+rejected[0].ID.String()+"/replay", admin.Token, nil), 409)
+	expectStatus(t, ts.request("POST", "/api/polka/webhooks", "", strings.Repeat("x", polkaMaxBodyBytes+1)), 413)
+
+	expectStatus(t, ts.request("GET", "/admin/webhooks/events", "", nil), 401)
+	expectStatus(t, ts.request("GET", "/admin/webhooks/events", session.Token, nil), 403)
+}
+
+func TestReset(t *testing.T) {
+	ts := newTestServer(t)
+	ts.signUp("gone@example.com")
+	admin := ts.signUpAdmin("admin@example.com")
+
+	ts.cfg.platform = "prod"
+	expectStatus(t, ts.request("POST", "/admin/reset", admin.Token, nil), 403)
+
+	ts.cfg.platform = "dev"
+	expectStatus(t, ts.request("POST", "/admin/reset", admin.Token, nil), 200)
+	expectStatus(t, ts.request("POST", "/api/login", "", map[string]string{"email": "gone@example.com", "password": "correct horse"}), 401)
+	// The reset removed the admin too.
+	expectStatus(t, ts.request("POST", "/admin/reset", admin.Token, nil), 401)
+}
+
+func TestAdminRoles(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.signUp("user@example.com")
+	admin := ts.signUpAdmin("admin@example.com")
+	if user.Role != roleUser {
+		t.Errorf("role = %q, want %q", user.Role, roleUser)
+	}
+
+	expectStatus(t, ts.request("GET", "/admin/metrics", "", nil), 401)
+	expectStatus(t, ts.request("GET", "/admin/metrics", user.Token, nil), 403)
+
+	// Admin powers need a login token, not a personal access token.
+	resp := ts.request("POST", "/api/tokens", admin.Token, map[string]any{"name": "admin", "scopes": []string{auth.ScopeChirpsRead}})
+	expectStatus(t, resp, 201)
+	pat := decode[PersonalAccessToken](t, resp)
+	expectStatus(t, ts.request("GET", "/admin/metrics", pat.Token, nil), 403)
+
+	rolePath := "/admin/users/" + user.ID.String() + "/role"
+	expectStatus(t, ts.request("PUT", rolePath, user.Token, map[string]string{"role": roleAdmin}), 403)
+	expectStatus(t, ts.request("PUT", rolePath, admin.Token, map[string]string{"role": "owner"}), 400)
+	expectStatus(t, ts.request("PUT", "/admin/users/"+admin.ID.String()+"/role", admin.Token, map[string]string{"role": roleUser}), 400)
+	expectStatus(t, ts.request("PUT", "/admin/users/"+uuid.NewString()+"/role", admin.Token, map[string]string{"role": roleUser}), 404)
+
+	resp = ts.request("PUT", rolePath, admin.Token, map[string]string{"role": roleAdmin})
+	expectStatus(t, resp, 200)
+	if promoted := decode[User](t, resp); promoted.Role != roleAdmin {
+		t.Errorf("role = %q, want %q", promoted.Role, roleAdmin)
+	}
+	// Roles are checked on every request, so the existing token now works
+	// and stops working again after a demotion.
+	expectStatus(t, ts.request("GET", "/admin/metrics", user.Token, nil), 200)
+	expectStatus(t, ts.request("PUT", "/admin/users/"+admin.ID.String()+"/role", user.Token, map[string]string{"role": roleModerator}), 200)
+	expectStatus(t, ts.request("GET", "/admin/metrics", admin.Token, nil), 403)
+}
+
+func TestModeration(t *testing.T) {
+	ts := newTestServer(t)
+	author := ts.signUp("author@example.com")
+	reporter := ts.signUp("reporter@example.com")
+	admin := ts.signUpAdmin("admin@example.com")
+	expectStatus(t, ts.request("PUT", "/admin/users/"+reporter.ID.String()+"/role", admin.Token, map[string]string{"role": roleModerator}), 200)
+	moderator := reporter
+
+	chirp := ts.postChirp(author.Token, "buy cheap watches")
+	reportPath := "/api/chirps/" + chirp.ID.String() + "/report"
+	expectStatus(t, ts.request("POST", reportPath, "", map[string]string{"reason": "spam"}), 401)
+	expectStatus(t, ts.request("POST", reportPath, moderator.Token, map[string]string{"reason": "boring"}), 400)
+	expectStatus(t, ts.request("POST", reportPath, author.Token, map[string]string{"reason": "spam"}), 400)
+	expectStatus(t, ts.request("POST", "/api/users/"+author.ID.String()+"/report", author.Token, map[string]string{"reason": "spam"}), 400)
+
+	resp := ts.request("POST", reportPath, moderator.Token, map[string]string{"reason": "spam", "details": "links everywhere"})
+	expectStatus(t, resp, 201)
+	report := decode[Report](t, resp)
+	if report.Status != reportOpen || report.TargetUserID != author.ID || report.ChirpBody == nil || *report.ChirpBody != chirp.Body {
+		t.Errorf("report = %+v", report)
+	}
+	resp = ts.request("POST", "/api/users/"+author.ID.String()+"/report", admin.Token, map[string]string{"reason": "harassment"})
+	expectStatus(t, resp, 201)
+	userReport := decode[Report](t, resp)
+
+	// The queue is for moderators, and a report must be claimed before it
+	// can be resolved, by the moderator who claimed it.
+	expectStatus(t, ts.request("GET", "/api/moderation/reports", author.Token, nil), 403)
+	resp = ts.request("GET", "/api/moderation/reports", moderator.Token, nil)
+	expectStatus(t, resp, 200)
+	if queue := decode[[]Report](t, resp); len(queue) != 2 || queue[0].ID != report.ID {
+		t.Fatalf("queue = %+v, want both reports oldest first", queue)
+	}
+	resolvePath := "/api/moderation/reports/" + report.ID.String() + "/resolve"
+	expectStatus(t, ts.request("POST", resolvePath, moderator.Token, map[string]string{"action": actionHideChirp}), 409)
+	expectStatus(t, ts.request("POST", "/api/moderation/reports/"+report.ID.String()+"/claim", moderator.Token, nil), 200)
+	expectStatus(t, ts.request("POST", "/api/moderation/reports/"+report.ID.String()+"/claim", admin.Token, nil), 409)
+	expectStatus(t, ts.request("POST", "/api/moderation/reports/"+uuid.NewString()+"/claim", admin.Token, nil), 404)
+	expectStatus(t, ts.request("POST", resolvePath, admin.Token, map[string]string{"action": actionHideChirp}), 409)
+	expectStatus(t, ts.request("POST", resolvePath, moderator.Token, map[string]string{"action": "ban"}), 400)
+
+	resp = ts.request("POST", resolvePath, moderator.Token, map[string]string{"action": actionHideChirp, "note": "spam"})
+	expectStatus(t, resp, 200)
+	if resolved := decode[Report](t, resp); resolved.Status != reportResolved || resolved.Resolution == nil || *resolved.Resolution != actionHideChirp {
+		t.Errorf("resolved report = %+v", resolved)
+	}
+
+	// Hidden chirps drop out of the feed but their author can still see them.
+	resp = ts.request("GET", "/api/chirps", "", nil)
+	expectStatus(t, resp, 200)
+	if chirps := decode[[]Chirp](t, resp); len(chirps) != 0 {
+		t.Errorf("chirps = %+v, want the hidden chirp left out", chirps)
+	}
+	expectStatus(t, ts.request("GET", "/api/chirps/"+chirp.ID.String(), "", nil), 404)
+	expectStatus(t, ts.request("GET", "/api/chirps/"+chirp.ID.String(), author.Token, nil), 200)
+	resp = ts.request("GET", "/api/chirps", author.Token, nil)
+	expectStatus(t, resp, 200)
+	if chirps := decode[[]Chirp](t, resp); len(chirps) != 1 || chirps[0].ID != chirp.ID {
+		t.Errorf("chirps for the author = %+v, want the hidden chirp listed", chirps)
+	}
+
+	resp = ts.request("GET", "/api/moderation/reports/"+report.ID.String(), moderator.Token, nil)
+	expectStatus(t, resp, 200)
+	trail := decode[Report](t, resp)
+	if len(trail.Actions) != 1 || trail.Actions[0].Action != actionHideChirp || *trail.Actions[0].ModeratorID != moderator.ID || trail.Actions[0].Note != "spam" {
+		t.Errorf("actions = %+v, want the hide decision", trail.Actions)
+	}
+	resp = ts.request("GET", "/admin/audit?target_id="+chirp.ID.String(), admin.Token, nil)
+	expectStatus(t, resp, 200)
+	if events := decode[[]AuditEvent](t, resp); len(events) != 1 || events[0].Action != auditChirpHidden || *events[0].ActorID != moderator.ID || string(events[0].Before) != `{"hidden_at":null}` {
+		t.Errorf("audit events for the chirp = %+v, want the hide", events)
+	}
+
+	// Suspending signs the user out everywhere.
+	userResolvePath := "/api/moderation/reports/" + userReport.ID.String() + "/resolve"
+	expectStatus(t, ts.request("POST", "/api/moderation/reports/"+userReport.ID.String()+"/claim", admin.Token, nil), 200)
+	expectStatus(t, ts.request("POST", userResolvePath, admin.Token, map[string]string{"action": actionSuspend}), 400)
+	expectStatus(t, ts.request("POST", userResolvePath, admin.Token, map[string]string{"action": actionSuspend, "suspend_for": "72h"}), 200)
+	suspended, err := ts.store.GetUserByID(context.Background(), author.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !suspended.SuspendedUntil.Valid || suspended.SuspendedUntil.Time.Before(time.Now().Add(71*time.Hour)) {
+		t.Errorf("suspended_until = %+v, want three days from now", suspended.SuspendedUntil)
+	}
+	expectStatus(t, ts.request("POST", "/api/refresh", author.RefreshToken, nil), 401)
+
+	resp = ts.request("GET", "/api/moderation/reports?status=resolved", moderator.Token, nil)
+	expectStatus(t, resp, 200)
+	if resolved := decode[[]Report](t, resp); len(resolved) != 2 {
+		t.Errorf("resolved reports = %+v, want both", resolved)
+	}
+	expectStatus(t, ts.request("GET", "/api/moderation/reports?status=closed", moderator.Token, nil), 400)
+}
+
+func TestModerationWarning(t *testing.T) {
+	ts := newTestServer(t)
+	author := ts.signUp("author@example.com")
+	admin := ts.signUpAdmin("admin@example.com")
+	chirp := ts.postChirp(author.Token, "you are all wrong")
+
+	resp := ts.request("POST", "/api/chirps/"+chirp.ID.String()+"/report", admin.Token, map[string]string{"reason": "harassment"})
+	expectStatus(t, resp, 201)
+	report := decode[Report](t, resp)
+	expectStatus(t, ts.request("POST", "/api/moderation/reports/"+report.ID.String()+"/claim", admin.Token, nil), 200)
+	expectStatus(t, ts.request("POST", "/api/moderation/reports/"+report.ID.String()+"/resolve", admin.Token, map[string]string{"action": actionWarn, "note": "keep it civil"}), 200)
+
+	msg := ts.mailer.last(t)
+	if msg.To != "author@example.com" || !strings.Contains(msg.Body, chirp.Body) || !strings.Contains(msg.Body, "keep it civil") {
+		t.Errorf("warning = %+v", msg)
+	}
+	expectStatus(t, ts.request("GET", "/api/chirps/"+chirp.ID.String(), "", nil), 200)
+}
+
+func TestReportClaims(t *testing.T) {
+	ts := newTestServer(t)
+	reporter := ts.signUp("reporter@example.com")
+	moderator := ts.signUp("moderator@example.com")
+	colleague := ts.signUp("colleague@example.com")
+	admin := ts.signUpAdmin("admin@example.com")
+	for _, id := range []uuid.UUID{moderator.ID, colleague.ID} {
+		expectStatus(t, ts.request("PUT", "/admin/users/"+id.String()+"/role", admin.Token, map[string]string{"role": roleModerator}), 200)
+	}
+
+	resp := ts.request("POST", "/api/users/"+colleague.ID.String()+"/report", reporter.Token, map[string]string{"reason": "harassment"})
+	expectStatus(t, resp, 201)
+	report := decode[Report](t, resp)
+	reportPath := "/api/moderation/reports/" + report.ID.String()
+
+	// Nobody handles reports about themselves, and only someone above the
+	// target can settle one.
+	expectStatus(t, ts.request("POST", reportPath+"/claim", colleague.Token, nil), 403)
+	expectStatus(t, ts.request("POST", reportPath+"/claim", moderator.Token, nil), 200)
+	expectStatus(t, ts.request("POST", reportPath+"/resolve", moderator.Token, map[string]string{"action": actionDismiss}), 403)
+
+	// A claim can be handed back, or taken back by someone above the claimer.
+	expectStatus(t, ts.request("POST", reportPath+"/unclaim", colleague.Token, nil), 403)
+	resp = ts.request("POST", reportPath+"/unclaim", admin.Token, nil)
+	expectStatus(t, resp, 200)
+	if reopened := decode[Report](t, resp); reopened.Status != reportOpen || reopened.ClaimedBy != nil {
+		t.Errorf("unclaimed report = %+v, want it open again", reopened)
+	}
+	expectStatus(t, ts.request("POST", reportPath+"/unclaim", admin.Token, nil), 409)
+	expectStatus(t, ts.request("POST", reportPath+"/claim", admin.Token, nil), 200)
+	expectStatus(t, ts.request("POST", reportPath+"/resolve", admin.Token, map[string]string{"action": actionDismiss}), 200)
+
+	// A claim taken back while the resolution is under way is a conflict.
+	resp = ts.request("POST", "/api/users/"+reporter.ID.String()+"/report", moderator.Token, map[string]string{"reason": "spam"})
+	expectStatus(t, resp, 201)
+	reportPath = "/api/moderation/reports/" + decode[Report](t, resp).ID.String()
+	expectStatus(t, ts.request("POST", reportPath+"/claim", admin.Token, nil), 200)
+	ts.cfg.dbQueries = unclaimingQuerier{ts.store}
+	expectStatus(t, ts.request("POST", reportPath+"/resolve", admin.Token, map[string]string{"action": actionDismiss}), 409)
+}
+
+// unclaimingQuerier loses every resolution race: the claim is released
+// just before the report is resolved.
+type unclaimingQuerier struct {
+	database.Querier
+}
+
+func (q unclaimingQuerier) ResolveReport(ctx context.Context, arg database.ResolveReportParams) (database.Report, error) {
+	_, err := q.UnclaimReport(ctx, database.UnclaimReportParams{ID: arg.ID, ClaimedBy: arg.ClaimedBy})
+	if err != nil {
+		return database.Report{}, err
+	}
+	return q.Querier.ResolveReport(ctx, arg)
+}
+
+func TestSuspensionAndShadowban(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.signUp("user@example.com")
+	viewer := ts.signUp("viewer@example.com")
+	moderator := ts.signUp("moderator@example.com")
+	admin := ts.signUpAdmin("admin@example.com")
+	expectStatus(t, ts.request("PUT", "/admin/users/"+moderator.ID.String()+"/role", admin.Token, map[string]string{"role": roleModerator}), 200)
+	chirp := ts.postChirp(user.Token, "hello")
+
+	suspensionPath := "/api/moderation/users/" + user.ID.String() + "/suspension"
+	expectStatus(t, ts.request("PUT", suspensionPath, viewer.Token, map[string]string{"suspend_for": "1h"}), 403)
+	expectStatus(t, ts.request("PUT", suspensionPath, moderator.Token, map[string]string{"suspend_for": "soon"}), 400)
+	expectStatus(t, ts.request("PUT", "/api/moderation/users/"+moderator.ID.String()+"/suspension", moderator.Token, map[string]string{"suspend_for": "1h"}), 400)
+	expectStatus(t, ts.request("PUT", "/api/moderation/users/"+admin.ID.String()+"/suspension", moderator.Token, map[string]string{"suspend_for": "1h"}), 403)
+	expectStatus(t, ts.request("PUT", "/api/moderation/users/"+uuid.NewString()+"/suspension", moderator.Token, map[string]string{"suspend_for": "1h"}), 404)
+
+	// Chirpy Red, so the edit below is refused for the suspension alone.
+	expectStatus(t, ts.polka(map[string]any{"id": "evt_audit", "event": "user.upgraded", "data": map[string]any{"user_id": user.ID}}), 204)
+	resp := ts.request("PUT", suspensionPath, moderator.Token, map[string]string{"suspend_for": "1h", "note": "cool off"})
+	expectStatus(t, resp, 200)
+	if restrictions := decode[UserRestrictions](t, resp); restrictions.SuspendedUntil == nil {
+		t.Errorf("restrictions = %+v, want a suspension", restrictions)
+	}
+	expectStatus(t, ts.request("POST", "/api/login", "", map[string]string{"email": "user@example.com", "password": "correct horse"}), 403)
+	expectStatus(t, ts.request("POST", "/api/chirps", user.Token, map[string]string{"body": "let me in"}), 403)
+	expectStatus(t, ts.request("PUT", "/api/chirps/"+chirp.ID.String(), user.Token, map[string]string{"body": "edited"}), 403)
+	expectStatus(t, ts.request("POST", "/api/users/"+viewer.ID.String()+"/report", user.Token, map[string]string{"reason": "spam"}), 403)
+	// The access token issued before the suspension stops working everywhere.
+	expectStatus(t, ts.request("GET", "/api/subscription", user.Token, nil), 403)
+	expectStatus(t, ts.request("PUT", "/api/users", user.Token, map[string]string{"email": "user@example.com", "password": "new horse"}), 403)
+	viewerChirp := ts.postChirp(viewer.Token, "report me if you can")
+	expectStatus(t, ts.request("POST", "/api/chirps/"+viewerChirp.ID.String()+"/report", user.Token, map[string]string{"reason": "spam"}), 403)
+	expectStatus(t, ts.request("DELETE", "/api/chirps/"+viewerChirp.ID.String(), viewer.Token, nil), 204)
+	expectStatus(t, ts.request("POST", "/api/login/magic", "", map[string]string{"email": "user@example.com"}), 202)
+	match := magicTokenPattern.FindStringSubmatch(ts.mailer.last(t).Body)
+	if match == nil {
+		t.Fatal("no login link was sent")
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, ts.request("POST", "/api/login/magic/redeem", "", map[string]string{"token": token}), 403)
+	// Chirps from before the suspension stay up.
+	expectStatus(t, ts.request("GET", "/api/chirps/"+chirp.ID.String(), "", nil), 200)
+
+	expectStatus(t, ts.request("DELETE", suspensionPath, moderator.Token, nil), 200)
+	user = ts.login("user@example.com", "correct horse")
+	ts.postChirp(user.Token, "back again")
+	expectStatus(t, ts.request("PUT", "/api/chirps/"+chirp.ID.String(), user.Token, map[string]string{"body": "edited"}), 200)
+
+	// A shadowbanned user sees their own chirps as usual; nobody else does.
+	shadowbanPath := "/api/moderation/users/" + user.ID.String() + "/shadowban"
+	expectStatus(t, ts.request("PUT", shadowbanPath, moderator.Token, map[string]bool{"shadowbanned": true}), 200)
+	ts.postChirp(viewer.Token, "visible")
+	countChirps := func(token string) int {
+		t.Helper()
+		resp := ts.request("GET", "/api/chirps", token, nil)
+		expectStatus(t, resp, 200)
+		return len(decode[[]Chirp](t, resp))
+	}
+	if n := countChirps(user.Token); n != 3 {
+		t.Errorf("shadowbanned user sees %d chirps, want 3", n)
+	}
+	if n := countChirps(viewer.Token); n != 1 {
+		t.Errorf("viewer sees %d chirps, want 1", n)
+	}
+	if n := countChirps(""); n != 1 {
+		t.Errorf("anonymous viewer sees %d chirps, want 1", n)
+	}
+	expectStatus(t, ts.request("GET", "/api/chirps/"+chirp.ID.String(), viewer.Token, nil), 404)
+	expectStatus(t, ts.request("GET", "/api/chirps/"+chirp.ID.String(), user.Token, nil), 200)
+
+	expectStatus(t, ts.request("PUT", shadowbanPath, moderator.Token, map[string]bool{"shadowbanned": false}), 200)
+	if n := countChirps(viewer.Token); n != 3 {
+		t.Errorf("viewer sees %d chirps after the shadowban is lifted, want 3", n)
+	}
+
+	resp = ts.request("GET", "/admin/audit?actor_id="+moderator.ID.String(), admin.Token, nil)
+	expectStatus(t, resp, 200)
+	events := decode[[]AuditEvent](t, resp)
+	want := []string{auditUserUnshadowbanned, auditUserShadowbanned, auditUserUnsuspended, auditUserSuspended}
+	if len(events) != len(want) {
+		t.Fatalf("moderator's audit events = %+v, want %v", events, want)
+	}
+	for i, event := range events {
+		if event.Action != want[i] || *event.TargetID != user.ID {
+			t.Errorf("audit event %d = %+v, want %s of the user", i, event, want[i])
+		}
+	}
+	suspended := events[3]
+	if string(suspended.Before) != `{"shadowbanned":false,"suspended_until":null}` || !strings.Contains(string(suspended.After), `"suspended_until":"`) {
+		t.Errorf("suspension snapshots = %s -> %s", suspended.Before, suspended.After)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.signUp("user@example.com")
+	admin := ts.signUpAdmin("admin@example.com")
+	start := time.Now()
+
+	chirp := ts.postChirp(user.Token, "soon gone")
+	req, err := http.NewRequest("DELETE", ts.url+"/api/chirps/"+chirp.ID.String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+user.Token)
+	req.Header.Set(requestIDHeader, "delete-1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	expectStatus(t, resp, 204)
+
+	expectStatus(t, ts.request("PUT", "/api/users", user.Token, map[string]string{"email": "renamed@example.com", "password": "battery staple"}), 200)
+	expectStatus(t, ts.polka(map[string]any{"id": "evt_suspend", "event": "user.upgraded", "data": map[string]any{"user_id": user.ID}}), 204)
+	expectStatus(t, ts.request("PUT", "/admin/users/"+user.ID.String()+"/role", admin.Token, map[string]string{"role": roleModerator}), 200)
+
+	expectStatus(t, ts.request("GET", "/admin/audit", user.Token, nil), 403)
+	expectStatus(t, ts.request("GET", "/admin/audit?since=yesterday", admin.Token, nil), 400)
+
+	resp = ts.request("GET", "/admin/audit?actor_id="+user.ID.String(), admin.Token, nil)
+	expectStatus(t, resp, 200)
+	events := decode[[]AuditEvent](t, resp)
+	if len(events) != 2 || events[0].Action != auditUserUpdated || events[1].Action != auditChirpDeleted {
+		t.Fatalf("events by the user = %+v, want the update and the deletion, newest first", events)
+	}
+	deleted := events[1]
+	if *deleted.TargetID != chirp.ID || deleted.RequestID == nil || *deleted.RequestID != "delete-1" || deleted.IP == nil || string(deleted.After) != "null" {
+		t.Errorf("deletion event = %+v", deleted)
+	}
+	var before Chirp
+	if err := json.Unmarshal(deleted.Before, &before); err != nil || before.Body != chirp.Body {
+		t.Errorf("deletion snapshot = %s, want the deleted chirp", deleted.Before)
+	}
+	if updated := events[0]; strings.Contains(string(updated.After), "hash") || !strings.Contains(string(updated.After), "renamed@example.com") {
+		t.Errorf("update snapshot = %s", updated.After)
+	}
+
+	resp = ts.request("GET", "/admin/audit?target_id="+user.ID.String()+"&since="+start.UTC().Format(time.RFC3339Nano), admin.Token, nil)
+	expectStatus(t, resp, 200)
+	events = decode[[]AuditEvent](t, resp)
+	if len(events) != 3 || events[0].Action != auditUserRoleChanged || events[1].Action != auditUserChirpyRedChanged {
+		t.Fatalf("events for the user = %+v, want the role change, the upgrade and the update", events)
+	}
+	if *events[0].ActorID != admin.ID || string(events[0].After) != `{"role":"moderator"}` {
+		t.Errorf("role change = %+v", events[0])
+	}
+	if events[1].ActorID != nil {
+		t.Errorf("upgrade actor = %v, want none for a billing webhook", *events[1].ActorID)
+	}
+
+	resp = ts.request("GET", "/admin/audit?until="+start.UTC().Format(time.RFC3339Nano), admin.Token, nil)
+	expectStatus(t, resp, 200)
+	if events := decode[[]AuditEvent](t, resp); len(events) != 1 || events[0].Action != auditUserRoleChanged {
+		t.Errorf("events before the test = %+v, want only the admin bootstrap", events)
+	}
+
+	// The log survives a reset, which is itself logged.
+	expectStatus(t, ts.request("POST", "/admin/reset", admin.Token, nil), 200)
+	logged, err := ts.store.ListAuditEvents(context.Background(), database.ListAuditEventsParams{Limit: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logged) != 6 || logged[0].Action != auditReset {
+		t.Errorf("audit log after reset = %+v", logged)
+	}
+}
+
+func TestBlocksAndMutes(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.signUp("alice@example.com")
+	bob := ts.signUp("bob@example.com")
+	carol := ts.signUp("carol@example.com")
+	aliceChirp := ts.postChirp(alice.Token, "from alice")
+	bobChirp := ts.postChirp(bob.Token, "from bob")
+	ts.postChirp(carol.Token, "from carol")
+
+	countChirps := func(token string) int {
+		t.Helper()
+		resp := ts.request("GET", "/api/chirps", token, nil)
+		expectStatus(t, resp, 200)
+		return len(decode[[]Chirp](t, resp))
+	}
+
+	blockPath := "/api/users/" + bob.ID.String() + "/block"
+	expectStatus(t, ts.request("PUT", "/api/users/"+alice.ID.String()+"/block", alice.Token, nil), 400)
+	expectStatus(t, ts.request("PUT", "/api/users/"+uuid.NewString()+"/block", alice.Token, nil), 404)
+	expectStatus(t, ts.request("PUT", blockPath, "", nil), 401)
+	expectStatus(t, ts.request("PUT", blockPath, alice.Token, nil), 204)
+	expectStatus(t, ts.request("PUT", blockPath, alice.Token, nil), 204)
+
+	resp := ts.request("GET", "/api/users/me/blocks", alice.Token, nil)
+	expectStatus(t, resp, 200)
+	if blocks := decode[[]RelatedUser](t, resp); len(blocks) != 1 || blocks[0].UserID != bob.ID {
+		t.Errorf("blocks = %+v, want bob", blocks)
+	}
+
+	// Listing needs only the read scope, which can't change the lists.
+	resp = ts.request("POST", "/api/tokens", alice.Token, map[string]any{"name": "reader", "scopes": []string{auth.ScopeProfileRead}})
+	expectStatus(t, resp, 201)
+	reader := decode[PersonalAccessToken](t, resp)
+	expectStatus(t, ts.request("GET", "/api/users/me/blocks", reader.Token, nil), 200)
+	expectStatus(t, ts.request("GET", "/api/users/me/mutes", reader.Token, nil), 200)
+	expectStatus(t, ts.request("DELETE", blockPath, reader.Token, nil), 403)
+
+	// A block hides chirps in both directions.
+	if n := countChirps(alice.Token); n != 2 {
+		t.Errorf("blocker sees %d chirps, want 2", n)
+	}
+	if n := countChirps(bob.Token); n != 2 {
+		t.Errorf("blocked user sees %d chirps, want 2", n)
+	}
+	if n := countChirps(carol.Token); n != 3 {
+		t.Errorf("bystander sees %d chirps, want 3", n)
+	}
+	expectStatus(t, ts.request("GET", "/api/chirps/"+bobChirp.ID.String(), alice.Token, nil), 404)
+	expectStatus(t, ts.request("GET", "/api/chirps/"+aliceChirp.ID.String(), bob.Token, nil), 404)
+	expectStatus(t, ts.request("GET", "/api/chirps/"+aliceChirp.ID.String(), "", nil), 200)
+
+	expectStatus(t, ts.request("DELETE", blockPath, alice.Token, nil), 204)
+	if n := countChirps(bob.Token); n != 3 {
+		t.Errorf("bob sees %d chirps after the unblock, want 3", n)
+	}
+
+	// A mute only hides the muted user's chirps from the muter's listing.
+	mutePath := "/api/users/" + carol.ID.String() + "/mute"
+	expectStatus(t, ts.request("PUT", mutePath, alice.Token, nil), 204)
+	resp = ts.request("GET", "/api/users/me/mutes", alice.Token, nil)
+	expectStatus(t, resp, 200)
+	if mutes := decode[[]RelatedUser](t, resp); len(mutes) != 1 || mutes[0].UserID != carol.ID {
+		t.Errorf("mutes = %+v, want carol", mutes)
+	}
+	if n := countChirps(alice.Token); n != 2 {
+		t.Errorf("muter sees %d chirps, want 2", n)
+	}
+	if n := countChirps(carol.Token); n != 3 {
+		t.Errorf("muted user sees %d chirps, want 3", n)
+	}
+	expectStatus(t, ts.request("DELETE", mutePath, alice.Token, nil), 204)
+	if n := countChirps(alice.Token); n != 3 {
+		t.Errorf("alice sees %d chirps after the unmute, want 3", n)
+	}
+}
+
+// cancellingQuerier cancels every deletion it lists as due, as a user
+// restoring their account mid-purge would.
+type cancellingQuerier struct {
+	database.Querier
+}
+
+func (q cancellingQuerier) GetUsersDueForDeletion(ctx context.Context) ([]database.User, error) {
+	users, err := q.Querier.GetUsersDueForDeletion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		_, err = q.ScheduleUserDeletion(ctx, database.ScheduleUserDeletionParams{ID: user.ID})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return users, nil
+}
+
+func TestAccountDeletion(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+	user := ts.signUp("user@example.com")
+	other := ts.signUp("other@example.com")
+	ts.postChirp(user.Token, "goodbye")
+	ts.postChirp(other.Token, "still here")
+
+	expectStatus(t, ts.request("DELETE", "/api/users/me", "", map[string]string{"password": "correct horse"}), 401)
+	expectStatus(t, ts.request("DELETE", "/api/users/me", user.Token, map[string]string{"password": "wrong"}), 403)
+	expectStatus(t, ts.request("DELETE", "/api/users/me/deletion", user.Token, nil), 409)
+
+	// A wrong password counts as a failed login, and only a login token can
+	// delete the account or export its data.
+	admin := ts.signUpAdmin("admin@example.com")
+	resp := ts.request("GET", "/metrics", admin.Token, nil)
+	expectStatus(t, resp, 200)
+	if body, _ := io.ReadAll(resp.Body); !strings.Contains(string(body), "chirpy_failed_logins_total 1") {
+		t.Errorf("/metrics doesn't count the wrong password as a failed login")
+	}
+	resp = ts.request("POST", "/api/tokens", user.Token, map[string]any{"name": "profile", "scopes": []string{auth.ScopeProfileWrite}})
+	expectStatus(t, resp, 201)
+	pat := decode[PersonalAccessToken](t, resp)
+	expectStatus(t, ts.request("DELETE", "/api/users/me", pat.Token, map[string]string{"password": "correct horse"}), 403)
+	expectStatus(t, ts.request("POST", "/api/users/me/export", pat.Token, nil), 403)
+	expectStatus(t, ts.request("GET", "/api/users/me/export", pat.Token, nil), 403)
+
+	resp = ts.request("DELETE", "/api/users/me", user.Token, map[string]string{"password": "correct horse"})
+	expectStatus(t, resp, 202)
+	scheduled := decode[User](t, resp)
+	if scheduled.DeletionScheduledAt == nil || time.Until(*scheduled.DeletionScheduledAt) < 29*24*time.Hour {
+		t.Fatalf("deletion_scheduled_at = %v, want the end of the grace period", scheduled.DeletionScheduledAt)
+	}
+	if msg := ts.mailer.last(t); msg.To != "user@example.com" || !strings.Contains(msg.Subject, "deleted") {
+		t.Errorf("deletion notice = %+v", msg)
+	}
+
+	// The account keeps working through the grace period, so it can be
+	// restored.
+	user = ts.login("user@example.com", "correct horse")
+	resp = ts.request("DELETE", "/api/users/me/deletion", user.Token, nil)
+	expectStatus(t, resp, 200)
+	if restored := decode[User](t, resp); restored.DeletionScheduledAt != nil {
+		t.Errorf("deletion_scheduled_at = %v after cancelling, want null", restored.DeletionScheduledAt)
+	}
+
+	// Nothing is purged before the grace period ends.
+	expectStatus(t, ts.request("DELETE", "/api/users/me", user.Token, map[string]string{"password": "correct horse"}), 202)
+	if err := ts.cfg.purgeDeletedAccounts(ctx); err != nil {
+		t.Fatal(err)
+	}
+	ts.login("user@example.com", "correct horse")
+
+	_, err := ts.store.ScheduleUserDeletion(ctx, database.ScheduleUserDeletionParams{
+		ID:                  user.ID,
+		DeletionScheduledAt: sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A cancellation landing after the purge has listed the account wins.
+	ts.cfg.dbQueries = cancellingQuerier{ts.store}
+	if err := ts.cfg.purgeDeletedAccounts(ctx); err != nil {
+		t.Fatal(err)
+	}
+	ts.cfg.dbQueries = ts.store
+	ts.login("user@example.com", "correct horse")
+
+	_, err = ts.store.ScheduleUserDeletion(ctx, database.ScheduleUserDeletionParams{
+		ID:                  user.ID,
+		DeletionScheduledAt: sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.cfg.purgeDeletedAccounts(ctx); err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, ts.request("POST", "/api/login", "", map[string]string{"email": "user@example.com", "password": "correct horse"}), 401)
+	resp = ts.request("GET", "/api/chirps", "", nil)
+	expectStatus(t, resp, 200)
+	if chirps := decode[[]Chirp](t, resp); len(chirps) != 1 || chirps[0].UserID != other.ID {
+		t.Errorf("chirps after the purge = %+v, want only the other user's", chirps)
+	}
+
+	events, err := ts.store.ListAuditEvents(ctx, database.ListAuditEventsParams{
+		TargetID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		Limit:    10,
+	})
+	if err != nil || len(events) != 4 || events[0].Action != auditUserDeleted {
+		t.Errorf("audit events for the deleted user = %+v, %v", events, err)
+	}
+}
+
+func TestDataExport(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+	user := ts.signUp("user@example.com")
+	ts.postChirp(user.Token, "exported")
+	expectStatus(t, ts.polka(map[string]any{"id": "evt_export", "event": "user.upgraded", "data": map[string]string{"user_id": user.ID.String()}}), 204)
+
+	expectStatus(t, ts.request("GET", "/api/users/me/export", user.Token, nil), 404)
+	resp := ts.request("POST", "/api/users/me/export", user.Token, nil)
+	expectStatus(t, resp, 202)
+	requested := decode[DataExport](t, resp)
+	if requested.Status != dataExportPending {
+		t.Errorf("status = %q, want %q", requested.Status, dataExportPending)
+	}
+	resp = ts.request("POST", "/api/users/me/export", user.Token, nil)
+	expectStatus(t, resp, 202)
+	if again := decode[DataExport](t, resp); again.ID != requested.ID {
+		t.Errorf("second request queued export %s, want %s again", again.ID, requested.ID)
+	}
+	expectStatus(t, ts.request("GET", "/api/users/me/export", user.Token, nil), 202)
+
+	if err := ts.cfg.generateDataExports(ctx); err != nil {
+		t.Fatal(err)
+	}
+	resp = ts.request("GET", "/api/users/me/export", user.Token, nil)
+	expectStatus(t, resp, 200)
+	if ct := resp.Header.Get("Content-Type"); ct != "application/zip" {
+		t.Errorf("Content-Type = %q, want application/zip", ct)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(contents)
+	}
+	for name, want := range map[string]string{
+		"profile.json":      "user@example.com",
+		"chirps.json":       "exported",
+		"sessions.json":     "created_at",
+		"subscription.json": "user.upgraded",
+	} {
+		if !strings.Contains(files[name], want) {
+			t.Errorf("%s = %q, want it to mention %q", name, files[name], want)
+		}
+	}
+	if strings.Contains(files["sessions.json"], user.RefreshToken) {
+		t.Error("sessions.json contains a refresh token")
+	}
+}
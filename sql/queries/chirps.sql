@@ -13,9 +13,24 @@ RETURNING *;
 DELETE FROM chirps;
 
 -- name: GetChirps :many
-SELECT *
+-- Hidden chirps and those of shadowbanned users are only listed for their
+-- author. Blocks hide chirps in both directions, mutes only from the muting
+-- viewer.
+SELECT chirps.*
 FROM chirps
-ORDER BY created_at ASC;
+JOIN users ON users.id = chirps.user_id
+WHERE (chirps.hidden_at IS NULL OR chirps.user_id = sqlc.narg('viewer_id'))
+AND (NOT users.shadowbanned OR chirps.user_id = sqlc.narg('viewer_id'))
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (user_blocks.blocker_id = sqlc.narg('viewer_id') AND user_blocks.blocked_id = chirps.user_id)
+    OR (user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = sqlc.narg('viewer_id'))
+)
+AND NOT EXISTS (
+    SELECT 1 FROM user_mutes
+    WHERE user_mutes.muter_id = sqlc.narg('viewer_id') AND user_mutes.muted_id = chirps.user_id
+)
+ORDER BY chirps.created_at ASC;
 
 -- name: GetChirp :one
 SELECT *
@@ -24,4 +39,24 @@ WHERE id = $1;
 
 -- name: DeleteChirpByID :exec
 DELETE FROM chirps
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $1, updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: CountChirpsByUserSince :one
+SELECT COUNT(*) FROM chirps
+WHERE user_id = $1 AND created_at > $2;
+
+-- name: HideChirp :exec
+UPDATE chirps
+SET hidden_at = NOW(), updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetChirpsByUser :many
+SELECT * FROM chirps
+WHERE user_id = $1
+ORDER BY created_at ASC;
@@ -0,0 +1,18 @@
+-- +goose Up
+
+CREATE TABLE inbound_webhooks(
+    id UUID PRIMARY KEY,
+    received_at TIMESTAMP NOT NULL,
+    source TEXT NOT NULL,
+    headers JSONB NOT NULL,
+    body TEXT NOT NULL,
+    status_code INTEGER,
+    outcome TEXT,
+    error TEXT,
+    replay_of UUID REFERENCES inbound_webhooks(id) ON DELETE SET NULL
+);
+
+CREATE INDEX inbound_webhooks_received_at_idx ON inbound_webhooks (received_at);
+
+-- +goose Down
+DROP TABLE inbound_webhooks;
@@ -0,0 +1,23 @@
+-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (id, created_at, actor_id, action, target_type, target_id, ip, request_id, before_state, after_state)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    ?1,
+    ?2,
+    ?3,
+    ?4,
+    ?5,
+    ?6,
+    ?7,
+    ?8
+);
+
+-- name: ListAuditEvents :many
+SELECT * FROM audit_events
+WHERE (sqlc.narg('actor_id') IS NULL OR actor_id = sqlc.narg('actor_id'))
+AND (sqlc.narg('target_id') IS NULL OR target_id = sqlc.narg('target_id'))
+AND (sqlc.narg('since') IS NULL OR created_at >= sqlc.narg('since'))
+AND (sqlc.narg('until') IS NULL OR created_at < sqlc.narg('until'))
+ORDER BY created_at DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
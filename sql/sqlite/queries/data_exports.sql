@@ -0,0 +1,39 @@
+-- name: CreateDataExport :one
+INSERT INTO data_exports (id, created_at, updated_at, user_id, status)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    ?1,
+    'pending'
+)
+RETURNING *;
+
+-- name: GetLatestDataExportForUser :one
+SELECT * FROM data_exports
+WHERE user_id = ?1
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: ClaimPendingDataExports :many
+-- Exports left running by a worker that died are picked up again once
+-- they have gone ten minutes without progress.
+UPDATE data_exports
+SET status = 'running', updated_at = NOW()
+WHERE id IN (
+    SELECT id FROM data_exports
+    WHERE status = 'pending'
+    OR (status = 'running' AND updated_at <= strftime('%Y-%m-%d %H:%M:%f+00:00', 'now', '-10 minutes'))
+    ORDER BY created_at
+    LIMIT ?1
+)
+RETURNING *;
+
+-- name: FinishDataExport :exec
+UPDATE data_exports
+SET status = ?2, archive = ?3, completed_at = NOW(), expires_at = ?4, updated_at = NOW()
+WHERE id = ?1;
+
+-- name: DeleteExpiredDataExports :execrows
+DELETE FROM data_exports
+WHERE expires_at <= NOW();
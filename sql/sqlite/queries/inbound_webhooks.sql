@@ -0,0 +1,27 @@
+-- name: CreateInboundWebhook :one
+INSERT INTO inbound_webhooks (id, received_at, source, headers, body, replay_of)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    ?1,
+    ?2,
+    ?3,
+    ?4
+)
+RETURNING *;
+
+-- name: FinishInboundWebhook :one
+UPDATE inbound_webhooks
+SET status_code = ?2, outcome = ?3, error = ?4
+WHERE id = ?1
+RETURNING *;
+
+-- name: GetInboundWebhook :one
+SELECT * FROM inbound_webhooks
+WHERE id = ?1;
+
+-- name: ListInboundWebhooks :many
+SELECT * FROM inbound_webhooks
+WHERE (sqlc.narg('outcome') IS NULL OR outcome = sqlc.narg('outcome'))
+ORDER BY received_at DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
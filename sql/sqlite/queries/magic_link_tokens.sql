@@ -0,0 +1,14 @@
+-- name: CreateMagicLinkToken :exec
+INSERT INTO magic_link_tokens (token_hash, created_at, user_id, expires_at)
+VALUES (
+    ?1,
+    NOW(),
+    ?2,
+    ?3
+);
+
+-- name: ConsumeMagicLinkToken :one
+UPDATE magic_link_tokens
+SET used_at = NOW()
+WHERE token_hash = ?1 AND used_at IS NULL
+RETURNING *;
@@ -0,0 +1,61 @@
+-- name: CreateReport :one
+INSERT INTO reports (id, created_at, updated_at, reporter_id, target_user_id, chirp_id, chirp_body, reason, details)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    ?1,
+    ?2,
+    ?3,
+    ?4,
+    ?5,
+    ?6
+)
+RETURNING *;
+
+-- name: GetReport :one
+SELECT * FROM reports
+WHERE id = ?1;
+
+-- name: ListReports :many
+SELECT * FROM reports
+WHERE status = ?1
+ORDER BY created_at ASC
+LIMIT ?2 OFFSET ?3;
+
+-- name: ClaimReport :one
+UPDATE reports
+SET status = 'claimed', claimed_by = ?2, claimed_at = NOW(), updated_at = NOW()
+WHERE id = ?1 AND status = 'open'
+RETURNING *;
+
+-- name: ResolveReport :one
+UPDATE reports
+SET status = 'resolved', resolution = ?3, resolved_at = NOW(), updated_at = NOW()
+WHERE id = ?1 AND status = 'claimed' AND claimed_by = ?2
+RETURNING *;
+
+-- name: UnclaimReport :one
+UPDATE reports
+SET status = 'open', claimed_by = NULL, claimed_at = NULL, updated_at = NOW()
+WHERE id = ?1 AND status = 'claimed' AND claimed_by = ?2
+RETURNING *;
+
+-- name: CreateModerationAction :one
+INSERT INTO moderation_actions (id, created_at, report_id, moderator_id, action, target_user_id, chirp_id, note)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    ?1,
+    ?2,
+    ?3,
+    ?4,
+    ?5,
+    ?6
+)
+RETURNING *;
+
+-- name: GetModerationActionsForReport :many
+SELECT * FROM moderation_actions
+WHERE report_id = ?1
+ORDER BY created_at ASC;
@@ -0,0 +1,77 @@
+-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (id, created_at, updated_at, user_id, url, secret, events)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    ?1,
+    ?2,
+    ?3,
+    ?4
+)
+RETURNING *;
+
+-- name: GetWebhookSubscription :one
+SELECT * FROM webhook_subscriptions
+WHERE id = ?1;
+
+-- name: GetWebhookSubscriptionsForUser :many
+SELECT * FROM webhook_subscriptions
+WHERE user_id = ?1
+ORDER BY created_at ASC;
+
+-- name: DeleteWebhookSubscription :execrows
+DELETE FROM webhook_subscriptions
+WHERE id = ?1 AND user_id = ?2;
+
+-- name: EnqueueWebhookDeliveries :exec
+-- Events only go to the subscriptions of the user they are about, so a
+-- webhook can't be used to watch other users' chirps.
+INSERT INTO webhook_deliveries (id, created_at, updated_at, subscription_id, event, payload, status, attempts, next_attempt_at)
+SELECT gen_random_uuid(), NOW(), NOW(), webhook_subscriptions.id, CAST(sqlc.arg('event') AS TEXT), CAST(sqlc.arg('payload') AS BLOB), 'pending', 0, NOW()
+FROM webhook_subscriptions
+WHERE EXISTS (
+    SELECT 1 FROM json_each(webhook_subscriptions.events)
+    WHERE json_each.value = sqlc.arg('event')
+)
+AND webhook_subscriptions.user_id = sqlc.arg('user_id');
+
+-- name: ClaimDueWebhookDeliveries :many
+-- SQLite serialises writers, so the claim needs no FOR UPDATE SKIP LOCKED
+-- to keep two workers from leasing the same delivery.
+UPDATE webhook_deliveries
+SET next_attempt_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now', '+5 minutes'), updated_at = NOW()
+WHERE id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at
+    LIMIT ?1
+)
+RETURNING *;
+
+-- name: RecordWebhookDeliveryAttempt :exec
+UPDATE webhook_deliveries
+SET attempts = attempts + 1,
+    status = ?2,
+    next_attempt_at = ?3,
+    last_status_code = ?4,
+    last_error = ?5,
+    delivered_at = ?6,
+    updated_at = NOW()
+WHERE id = ?1;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = ?1;
+
+-- name: GetWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE subscription_id = ?1
+ORDER BY created_at DESC
+LIMIT 100;
+
+-- name: RedeliverWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = 'pending', attempts = 0, next_attempt_at = NOW(), updated_at = NOW()
+WHERE id = ?1
+RETURNING *;
@@ -0,0 +1,32 @@
+-- name: BlockUser :exec
+INSERT INTO user_blocks (blocker_id, blocked_id, created_at)
+VALUES (?1, ?2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnblockUser :exec
+DELETE FROM user_blocks
+WHERE blocker_id = ?1 AND blocked_id = ?2;
+
+-- name: GetBlocksForUser :many
+SELECT * FROM user_blocks
+WHERE blocker_id = ?1
+ORDER BY created_at ASC;
+
+-- name: CountBlocksBetween :one
+SELECT COUNT(*) FROM user_blocks
+WHERE (blocker_id = sqlc.arg('user_id') AND blocked_id = sqlc.arg('other_user_id'))
+OR (blocker_id = sqlc.arg('other_user_id') AND blocked_id = sqlc.arg('user_id'));
+
+-- name: MuteUser :exec
+INSERT INTO user_mutes (muter_id, muted_id, created_at)
+VALUES (?1, ?2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnmuteUser :exec
+DELETE FROM user_mutes
+WHERE muter_id = ?1 AND muted_id = ?2;
+
+-- name: GetMutesForUser :many
+SELECT * FROM user_mutes
+WHERE muter_id = ?1
+ORDER BY created_at ASC;
@@ -0,0 +1,85 @@
+-- name: CreateUser :one
+INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    ?1,
+    ?2
+)
+RETURNING *;
+
+-- name: DeleteUsers :exec
+DELETE FROM users;
+
+-- name: GetUser :one
+SELECT * FROM users
+WHERE email = ?1;
+
+-- name: SetUserEmailPassword :one
+UPDATE users
+SET email = ?1, hashed_password = ?2
+WHERE id = ?3
+RETURNING *;
+
+-- name: SetUserChirpyRed :one
+UPDATE users
+SET is_chirpy_red = TRUE
+WHERE id = ?1
+RETURNING *;
+
+-- name: SetUserPassword :exec
+UPDATE users
+SET hashed_password = ?1, updated_at = NOW()
+WHERE id = ?2;
+
+-- name: GetUserPasswordHashes :many
+SELECT id, hashed_password FROM users;
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = ?1;
+
+-- name: SetUserChirpyRedStatus :exec
+UPDATE users
+SET is_chirpy_red = ?2, updated_at = NOW()
+WHERE id = ?1;
+
+-- name: SetUserRole :one
+UPDATE users
+SET role = ?2, updated_at = NOW()
+WHERE id = ?1
+RETURNING *;
+
+-- name: SuspendUser :one
+UPDATE users
+SET suspended_until = ?2, updated_at = NOW()
+WHERE id = ?1
+RETURNING *;
+
+-- name: SetUserShadowbanned :one
+UPDATE users
+SET shadowbanned = ?2, updated_at = NOW()
+WHERE id = ?1
+RETURNING *;
+
+-- name: ScheduleUserDeletion :one
+UPDATE users
+SET deletion_scheduled_at = ?2, updated_at = NOW()
+WHERE id = ?1
+RETURNING *;
+
+-- name: GetUsersDueForDeletion :many
+SELECT * FROM users
+WHERE deletion_scheduled_at <= NOW()
+ORDER BY deletion_scheduled_at;
+
+-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = ?1;
+
+-- name: DeleteUserIfDue :execrows
+-- Leaves the account alone if its deletion was cancelled after it was
+-- picked up by GetUsersDueForDeletion.
+DELETE FROM users
+WHERE id = ?1 AND deletion_scheduled_at <= NOW();
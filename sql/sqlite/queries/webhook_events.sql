@@ -0,0 +1,13 @@
+-- name: ClaimWebhookEvent :execrows
+INSERT INTO webhook_events (event_id, source, event, received_at)
+VALUES (
+    ?1,
+    ?2,
+    ?3,
+    NOW()
+)
+ON CONFLICT (event_id) DO NOTHING;
+
+-- name: ReleaseWebhookEvent :exec
+DELETE FROM webhook_events
+WHERE event_id = ?1;
@@ -0,0 +1,8 @@
+// Package schema embeds the SQLite goose migrations, which track the
+// Postgres ones in sql/schema.
+package schema
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	planChirpyRed = "chirpy_red"
+
+	subscriptionActive    = "active"
+	subscriptionCancelled = "cancelled"
+	subscriptionExpired   = "expired"
+
+	subscriptionPeriod = 30 * 24 * time.Hour
+)
+
+var (
+	errUserNotFound         = errors.New("user not found")
+	errSubscriptionNotFound = errors.New("subscription not found")
+)
+
+// applySubscriptionEvent moves a user's Chirpy Red subscription through its
+// lifecycle. periodEnd comes from the billing provider and may be zero, in
+// which case a standard period is assumed. A cancelled subscription keeps
+// its benefits until the period ends; a downgrade revokes them at once.
+func (cfg *apiConfig) applySubscriptionEvent(ctx context.Context, userID uuid.UUID, event string, periodEnd time.Time) error {
+	return cfg.withTx(ctx, func(q database.Querier) error {
+		existing, err := q.GetSubscriptionByUser(ctx, userID)
+		hasSubscription := err == nil
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		status := subscriptionActive
+		isChirpyRed := true
+		switch event {
+		case "user.upgraded":
+			if periodEnd.IsZero() {
+				// An upgrade mustn't cut short a period already paid for.
+				periodEnd = time.Now().Add(subscriptionPeriod)
+				if hasSubscription && existing.CurrentPeriodEnd.After(periodEnd) {
+					periodEnd = existing.CurrentPeriodEnd
+				}
+			}
+		case "user.renewed":
+			if periodEnd.IsZero() {
+				start := time.Now()
+				if hasSubscription && existing.CurrentPeriodEnd.After(start) {
+					start = existing.CurrentPeriodEnd
+				}
+				periodEnd = start.Add(subscriptionPeriod)
+			}
+		case "user.cancelled":
+			if !hasSubscription {
+				return errSubscriptionNotFound
+			}
+			status = subscriptionCancelled
+			periodEnd = existing.CurrentPeriodEnd
+			// A late or repeated cancel mustn't bring back a subscription that
+			// has already ended.
+			if existing.Status == subscriptionExpired || !periodEnd.After(time.Now()) {
+				status = subscriptionExpired
+				isChirpyRed = false
+			}
+		case "user.downgraded":
+			if !hasSubscription {
+				return errSubscriptionNotFound
+			}
+			status = subscriptionExpired
+			periodEnd = time.Now()
+			isChirpyRed = false
+		default:
+			return nil
+		}
+
+		user, err := q.GetUserByID(ctx, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if isChirpyRed {
+			_, err = q.SetUserChirpyRed(ctx, userID)
+		} else {
+			err = q.SetUserChirpyRedStatus(ctx, database.SetUserChirpyRedStatusParams{
+				ID:          userID,
+				IsChirpyRed: false,
+			})
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return errUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		subscription, err := q.UpsertSubscription(ctx, database.UpsertSubscriptionParams{
+			UserID:           userID,
+			Plan:             planChirpyRed,
+			Status:           status,
+			CurrentPeriodEnd: periodEnd,
+		})
+		if err != nil {
+			return err
+		}
+		before := map[string]any{"is_chirpy_red": user.IsChirpyRed, "subscription_status": nil}
+		if hasSubscription {
+			before["subscription_status"] = existing.Status
+		}
+		err = writeAudit(ctx, q, auditEvent{
+			Action:     auditUserChirpyRedChanged,
+			TargetType: auditTargetUser,
+			TargetID:   userID,
+			Before:     before,
+			After:      map[string]any{"is_chirpy_red": isChirpyRed, "subscription_status": status, "event": event},
+		})
+		if err != nil {
+			return err
+		}
+		return q.CreateSubscriptionEvent(ctx, database.CreateSubscriptionEventParams{
+			SubscriptionID:   subscription.ID,
+			Event:            event,
+			Status:           status,
+			CurrentPeriodEnd: periodEnd,
+		})
+	})
+}
+
+// expireSubscriptions ends every subscription whose period has lapsed and
+// removes the Chirpy Red flag from its user, in one transaction so an event
+// applied meanwhile can't be overwritten.
+func (cfg *apiConfig) expireSubscriptions(ctx context.Context) error {
+	return cfg.withTx(ctx, func(q database.Querier) error {
+		expired, err := q.ExpireLapsedSubscriptions(ctx)
+		if err != nil {
+			return err
+		}
+		for _, subscription := range expired {
+			revoked, err := q.RevokeExpiredChirpyRed(ctx, subscription.UserID)
+			if err != nil {
+				return err
+			}
+			if revoked > 0 {
+				err = writeAudit(ctx, q, auditEvent{
+					Action:     auditUserChirpyRedChanged,
+					TargetType: auditTargetUser,
+					TargetID:   subscription.UserID,
+					After:      map[string]any{"is_chirpy_red": false, "subscription_status": subscription.Status, "event": "subscription.expired"},
+				})
+				if err != nil {
+					return err
+				}
+			}
+			err = q.CreateSubscriptionEvent(ctx, database.CreateSubscriptionEventParams{
+				SubscriptionID:   subscription.ID,
+				Event:            "subscription.expired",
+				Status:           subscription.Status,
+				CurrentPeriodEnd: subscription.CurrentPeriodEnd,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (cfg *apiConfig) runSubscriptionExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := cfg.expireSubscriptions(ctx)
+		if err != nil {
+			slog.Error("couldn't expire subscriptions", "error", err)
+		}
+		cfg.heartbeats.beat(workerSubscriptionExpiry, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
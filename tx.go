@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/arglp/chirpy/internal/database"
+	"github.com/arglp/chirpy/internal/sqlitestore"
+)
+
+// withTx runs fn with queries bound to a single transaction, committing if
+// fn returns nil and rolling back otherwise. fn must make every query
+// through q: on SQLite the pool has one connection, which the transaction
+// holds until it ends.
+//
+// Queries that can't be bound to a transaction, like the in-memory store
+// used by the handler tests, run fn directly without one being begun.
+func (cfg *apiConfig) withTx(ctx context.Context, fn func(q database.Querier) error) error {
+	var bind func(tx *sql.Tx) database.Querier
+	switch queries := cfg.dbQueries.(type) {
+	case *database.Queries:
+		bind = func(tx *sql.Tx) database.Querier { return queries.WithTx(tx) }
+	case *sqlitestore.Store:
+		bind = func(tx *sql.Tx) database.Querier { return queries.WithTx(tx) }
+	}
+	if cfg.db == nil || bind == nil {
+		return fn(cfg.dbQueries)
+	}
+
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(bind(tx))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}